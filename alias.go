@@ -16,7 +16,7 @@ var aliasCommands = &Options{
 		"remove",
 	},
 	descriptions: []string{
-		"add an alias: alias add <name> <year/month/day>",
+		"add an alias: alias add <name> <date>",
 		"remove an alias by name",
 	},
 }
@@ -60,18 +60,14 @@ func (a *aliases) String() string {
 }
 
 func (a *aliases) save(path string) error {
-	pt := filepath.Join(path, tagebuchAliases)
-	f, err := os.OpenFile(pt, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
+	var b strings.Builder
+	for name, date := range a.a {
+		b.WriteString(name + "=" + date + "\n")
 	}
-	defer f.Close()
 
-	for name, date := range a.a {
-		_, err = f.WriteString(name + "=" + date + "\n")
-		if err != nil {
-			return err
-		}
+	err := writeFileAtomic(filepath.Join(path, tagebuchAliases), strings.NewReader(b.String()))
+	if err != nil {
+		return err
 	}
 
 	err = syncPush(path)
@@ -88,6 +84,11 @@ func aliasLoad(path string) (*aliases, error) {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
+	return aliasRead(path)
+}
+
+// aliasRead reads the journal's aliases without syncing.
+func aliasRead(path string) (*aliases, error) {
 	a := &aliases{a: make(map[string]string)}
 
 	pt := filepath.Join(path, tagebuchAliases)
@@ -132,28 +133,34 @@ func aliasList(path string) error {
 
 func aliasAdd(path string, x []string) error {
 	if len(x) < 2 {
-		return fmt.Errorf("usage: alias add <name> <year/month/day>")
+		return fmt.Errorf("usage: alias add <name> <date>")
 	}
 
-	if len(x) > 2 {
-		return fmt.Errorf("trailing commands: %v", x[2:])
+	name := strings.TrimSpace(x[0])
+	if isBuiltinDate(name) {
+		return fmt.Errorf("invalid alias name: %v is already a date", name)
 	}
 
-	name := strings.TrimSpace(x[0])
-	date := strings.TrimSpace(x[1])
+	t, rest, err := parseDateArg(path, x[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("trailing commands: %v", rest)
+	}
 
-	// validate date format
-	parts := strings.Split(date, "/")
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid date format: %v (expected year/month/day)", date)
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	a, err := aliasLoad(path)
 	if err != nil {
 		return err
 	}
 
-	a.a[name] = date
+	a.a[name] = dateString(t)
 	return a.save(path)
 }
 
@@ -168,6 +175,12 @@ func aliasRemove(path string, x []string) error {
 
 	name := strings.TrimSpace(x[0])
 
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	a, err := aliasLoad(path)
 	if err != nil {
 		return err
@@ -180,12 +193,3 @@ func aliasRemove(path string, x []string) error {
 	delete(a.a, name)
 	return a.save(path)
 }
-
-// aliasLookup returns the date for a given alias name, or empty string if not found
-func aliasLookup(path, name string) (string, error) {
-	a, err := aliasLoad(path)
-	if err != nil {
-		return "", err
-	}
-	return a.a[name], nil
-}
@@ -40,3 +40,29 @@ func TestAproposMulti(t *testing.T) {
 		t.Fatal("invalid error", err)
 	}
 }
+
+func TestBaseShortcuts(t *testing.T) {
+	// the commands before any had a shared prefix, each prefix of which
+	// should still resolve to the same command
+	commands := []string{"init", "edit", "print", "todo", "search", "calendar", "list", "sync", "alias", "files", "serve"}
+
+	for _, c := range commands {
+		for i := 1; i <= len(c); i++ {
+			prefix := c[:i]
+			if _, err := Apropos(prefix, commands); err != nil {
+				continue
+			}
+			r, err := baseCommand(prefix)
+			if err != nil {
+				t.Fatal(prefix, err)
+			}
+			if r != c {
+				t.Fatalf("%v: got %v, want %v", prefix, r, c)
+			}
+		}
+	}
+
+	if r, err := baseCommand("tag"); err != nil || r != "tag" {
+		t.Fatal("invalid tag command", r, err)
+	}
+}
@@ -1,42 +1,84 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 var baseCommands = &Options{
 	commands: []string{
 		"init",
 		"edit",
+		"note",
 		"print",
 		"todo",
 		"search",
+		"index",
 		"calendar",
 		"list",
 		"sync",
 		"alias",
 		"files",
 		"serve",
+		"config",
+		"export",
+		"import",
+		"encrypt",
+		"tags",
+		"tag",
+		"stats",
 	},
 	descriptions: []string{
 		"initialize a new tagebuch",
 		"edit an entry",
+		"append a timestamped line to an entry",
 		"print an entry",
 		"interact with todos",
 		"search within a tagebuch",
+		"manage the search index",
 		"show calendar of entries",
 		"list all days with entries",
 		"sync with git remote",
 		"manage named aliases to dates",
 		"manage files attached to entries",
-		"serve a web UI (currently only for todo lists)",
+		"serve a web UI for the journal, or every journal if none is named",
+		"view or change journal settings",
+		"export entries, files, aliases and todos as md, html or json",
+		"import entries from jrnl, Day One or markdown files",
+		"encrypt or decrypt the journal",
+		"list #tags and @mentions in entries with counts",
+		"list entries with a #tag or @mention",
+		"show statistics and writing streaks",
 	},
 }
 
+// baseShortcuts resolves prefixes that match several commands: those that
+// matched a single command before newer commands started with the same
+// letters, and commands that are a prefix of another.
+var baseShortcuts = map[string]string{
+	"c":   "calendar",
+	"e":   "edit",
+	"i":   "init",
+	"in":  "init",
+	"t":   "todo",
+	"tag": "tag",
+}
+
+// baseCommand resolves a command name or prefix.
+func baseCommand(name string) (string, error) {
+	r, err := Apropos(name, baseCommands.commands)
+	if c, ok := baseShortcuts[name]; ok && errors.Is(err, ErrMultipleMatches) {
+		return c, nil
+	}
+	return r, err
+}
+
 func base(path string, x []string) error {
 	if len(x) == 0 {
 		return fmt.Errorf("command required. Options are:\n%v", baseCommands)
 	}
 
-	r, err := Apropos(x[0], baseCommands.commands)
+	r, err := baseCommand(x[0])
 	if err != nil {
 		return err
 	}
@@ -46,12 +88,16 @@ func base(path string, x []string) error {
 		return initTagebuch(path, x[1:])
 	case "edit":
 		return edit(path, x[1:])
+	case "note":
+		return note(path, x[1:])
 	case "print":
 		return printEntry(path, x[1:])
 	case "todo":
 		return todo(path, x[1:])
 	case "search":
 		return search(path, x[1:])
+	case "index":
+		return index(path, x[1:])
 	case "calendar":
 		return calendar(path, x[1:])
 	case "list":
@@ -64,6 +110,20 @@ func base(path string, x []string) error {
 		return files(path, x[1:])
 	case "serve":
 		return serve(path, x[1:])
+	case "config":
+		return configCommand(path, x[1:])
+	case "export":
+		return export(path, x[1:])
+	case "import":
+		return importJournal(path, x[1:])
+	case "encrypt":
+		return encrypt(path, x[1:])
+	case "tags":
+		return tags(path, x[1:])
+	case "tag":
+		return tag(path, x[1:])
+	case "stats":
+		return stats(path, x[1:])
 	default:
 		return fmt.Errorf("invalid command %v", r)
 	}
@@ -5,7 +5,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +22,42 @@ var calendarCommands = &Options{
 	},
 }
 
+// calendarOptions are the flags calendar takes. They may be anywhere among
+// its arguments, and are parsed by hand as dates such as -1 look like flags.
+type calendarOptions struct {
+	tag    string // only highlight entries with this tag
+	months int    // number of months to show
+	heat   bool   // shade days by entry length
+}
+
+func parseCalendarFlags(x []string) (*calendarOptions, []string, error) {
+	o := &calendarOptions{months: 1}
+	var rest []string
+	for i := 0; i < len(x); i++ {
+		switch x[i] {
+		case "-heat":
+			o.heat = true
+		case "-t", "-n":
+			if i == len(x)-1 {
+				return nil, nil, fmt.Errorf("%v requires a value", x[i])
+			}
+			if x[i] == "-t" {
+				o.tag = x[i+1]
+			} else {
+				n, err := strconv.Atoi(x[i+1])
+				if err != nil || n < 1 {
+					return nil, nil, fmt.Errorf("invalid number of months: %v", x[i+1])
+				}
+				o.months = n
+			}
+			i++
+		default:
+			rest = append(rest, x[i])
+		}
+	}
+	return o, rest, nil
+}
+
 func calendar(path string, x []string) error {
 	err := validate(path)
 	if err != nil {
@@ -28,12 +66,22 @@ func calendar(path string, x []string) error {
 
 	var year, month int
 
+	o, x, err := parseCalendarFlags(x)
+	if err != nil {
+		return err
+	}
+
 	// default to this month if no argument provided
 	if len(x) == 0 {
 		now := time.Now()
 		year = now.Year()
 		month = int(now.Month())
-		return showCalendar(path, year, month, x)
+		return showCalendar(path, year, month, o, x)
+	} else if len(x[0]) == 4 && isDigits(x[0]) {
+		// a whole year
+		year, _ = strconv.Atoi(x[0])
+		o.months = 12
+		return showCalendar(path, year, 1, o, x[1:])
 	} else if f := splitSlash(x[0]); len(f) == 2 {
 		// check for a specific year/month first
 		year, err = strconv.Atoi(f[0])
@@ -44,10 +92,13 @@ func calendar(path string, x []string) error {
 		if err != nil {
 			return fmt.Errorf("invalid month: %v: %v", f[1], err)
 		}
+	} else if t, rest, err := parseDateArg(path, x); err == nil {
+		// any date shows the month containing it
+		return showCalendar(path, t.Year(), int(t.Month()), o, rest)
 	} else {
 		r, err := Apropos(x[0], calendarCommands.commands)
 		if err != nil {
-			return fmt.Errorf("%w\n%v", err, monthHelp)
+			return fmt.Errorf("%w\n%v\n%v\n%v", err, monthHelp, yearHelp, dateHelp)
 		}
 
 		when := time.Now()
@@ -66,10 +117,13 @@ func calendar(path string, x []string) error {
 		return fmt.Errorf("invalid month: %v", month)
 	}
 
-	return showCalendar(path, year, month, x[1:])
+	return showCalendar(path, year, month, o, x[1:])
 }
 
-const monthHelp = "year/month : Specific month"
+const (
+	monthHelp = "year/month : Specific month"
+	yearHelp  = "year       : Every month of a year"
+)
 
 func splitSlash(s string) []string {
 	var parts []string
@@ -86,7 +140,32 @@ func splitSlash(s string) []string {
 	return parts
 }
 
-func showCalendar(path string, year, month int, x []string) error {
+// calendarColumns is how many months are shown side by side.
+const calendarColumns = 3
+
+// heatWords are the entry lengths, in words, at which a day is shaded
+// darker in a heatmap.
+var heatWords = []int{50, 150, 400}
+
+// heatColors shade days from the shortest entries to the longest.
+var heatColors = []string{
+	"\033[48;5;22m\033[97m",
+	"\033[48;5;28m\033[97m",
+	"\033[48;5;34m\033[30m",
+	"\033[48;5;40m\033[30m",
+}
+
+// calendarMonth is what a month's calendar marks on each day.
+type calendarMonth struct {
+	year, month int
+	entries     map[int]bool
+	files       map[int]bool
+	heat        map[int]int // heatColors index, if a heatmap
+}
+
+// showCalendar renders o.months months starting with year/month, three to a
+// row, highlighting days with entries, or only those with o.tag if set.
+func showCalendar(path string, year, month int, o *calendarOptions, x []string) error {
 	if len(x) != 0 {
 		return fmt.Errorf("trailing commands: %v", x)
 	}
@@ -96,10 +175,47 @@ func showCalendar(path string, year, month int, x []string) error {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	// find which days have entries and files
+	var months [][]string
+	for i := 0; i < o.months; i++ {
+		t := time.Date(year, time.Month(month)+time.Month(i), 1, 0, 0, 0, 0, time.Local)
+		m, err := scanMonth(path, t.Year(), int(t.Month()), o)
+		if err != nil {
+			return err
+		}
+
+		weeks := 0
+		if o.months > 1 {
+			// line up months side by side
+			weeks = 6
+		}
+		months = append(months, renderCalendar(m, weeks))
+	}
+
+	for i := 0; i < len(months); i += calendarColumns {
+		row := months[i:min(i+calendarColumns, len(months))]
+		if i > 0 {
+			fmt.Println()
+		}
+		for line := range row[0] {
+			var parts []string
+			for _, m := range row {
+				parts = append(parts, m[line])
+			}
+			fmt.Println(strings.Join(parts, " "))
+		}
+	}
+	return nil
+}
+
+// scanMonth finds which days of a month have entries and files.
+func scanMonth(path string, year, month int, o *calendarOptions) (*calendarMonth, error) {
 	monthPath := filepath.Join(path, fmt.Sprintf("%v/%v", year, month))
-	daysWithEntries := make(map[int]bool)
-	daysWithFiles := make(map[int]bool)
+	m := &calendarMonth{
+		year:    year,
+		month:   month,
+		entries: make(map[int]bool),
+		files:   make(map[int]bool),
+	}
 
 	filepath.WalkDir(monthPath, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -114,24 +230,46 @@ func showCalendar(path string, year, month int, x []string) error {
 				dayStr := filepath.Base(dir)
 				day, err := strconv.Atoi(dayStr)
 				if err == nil {
-					daysWithEntries[day] = true
+					m.entries[day] = true
 				}
 			}
 		}
 		return nil
 	})
 
+	if o.tag != "" || o.heat {
+		if o.heat {
+			m.heat = make(map[int]int)
+		}
+		for day := range m.entries {
+			text, err := entryText(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+			if err != nil {
+				return nil, err
+			}
+			if o.tag != "" && !slices.ContainsFunc(extractTags(text), func(t string) bool { return matchTag(t, o.tag) }) {
+				delete(m.entries, day)
+				continue
+			}
+			if o.heat {
+				words := len(strings.Fields(text))
+				level := 0
+				for level < len(heatWords) && words >= heatWords[level] {
+					level++
+				}
+				m.heat[day] = level
+			}
+		}
+	}
+
 	// check for files in each day
 	for day := 1; day <= daysIn(month, year); day++ {
 		dayPath := filepath.Join(monthPath, strconv.Itoa(day))
 		if hasFilesInDay(dayPath) {
-			daysWithFiles[day] = true
+			m.files[day] = true
 		}
 	}
 
-	// render calendar
-	renderCalendar(year, month, daysWithEntries, daysWithFiles)
-	return nil
+	return m, nil
 }
 
 // ANSI color codes
@@ -142,17 +280,24 @@ const (
 	colorBold  = "\033[1m"
 )
 
-func renderCalendar(year, month int, entries map[int]bool, files map[int]bool) {
+// renderCalendar returns the lines of a month's calendar, with at least
+// weeks rows of days.
+func renderCalendar(m *calendarMonth, weeks int) []string {
+	year, month := m.year, m.month
 	t := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	monthName := t.Month().String()
 
+	var lines []string
+
 	// header - width is 36 to match the calendar body (7 cells × 5 chars + 1)
 	header := fmt.Sprintf("%s %d", monthName, year)
-	fmt.Printf("┌──────────────────────────────────┐\n")
-	fmt.Printf("│%s│\n", centerString(header, 34))
-	fmt.Printf("├────┬────┬────┬────┬────┬────┬────┤\n")
-	fmt.Printf("│ Su │ Mo │ Tu │ We │ Th │ Fr │ Sa │\n")
-	fmt.Printf("├────┼────┼────┼────┼────┼────┼────┤\n")
+	lines = append(lines,
+		"┌──────────────────────────────────┐",
+		fmt.Sprintf("│%s│", centerString(header, 34)),
+		"├────┬────┬────┬────┬────┬────┬────┤",
+		"│ Su │ Mo │ Tu │ We │ Th │ Fr │ Sa │",
+		"├────┼────┼────┼────┼────┼────┼────┤",
+	)
 
 	// find first day of month and number of days
 	firstWeekday := int(t.Weekday())
@@ -161,36 +306,53 @@ func renderCalendar(year, month int, entries map[int]bool, files map[int]bool) {
 	// print calendar grid
 	day := 1
 	for week := 0; week < 6; week++ {
-		if day > daysInMonth {
+		if day > daysInMonth && week >= weeks {
 			break
 		}
-		fmt.Print("│")
+		line := "│"
 		for weekday := 0; weekday < 7; weekday++ {
 			if week == 0 && weekday < firstWeekday {
-				fmt.Print("    │")
+				line += "    │"
 			} else if day > daysInMonth {
-				fmt.Print("    │")
+				line += "    │"
 			} else {
-				hasEntry := entries[day]
-				hasFiles := files[day]
-				if hasEntry && hasFiles {
+				hasEntry := m.entries[day]
+				hasFiles := m.files[day]
+				marker := " "
+				if hasFiles {
+					marker = colorBlue + "*"
+				}
+				if hasEntry && m.heat != nil {
+					// shaded by length, with any files marker
+					line += fmt.Sprintf(" %s%s%2d%s%s%s│", colorBold, heatColors[m.heat[day]], day, colorReset, marker, colorReset)
+				} else if hasEntry && hasFiles {
 					// both entry and files: green with * marker
-					fmt.Printf(" %s%s%2d%s*%s│", colorBold, colorGreen, day, colorBlue, colorReset)
+					line += fmt.Sprintf(" %s%s%2d%s*%s│", colorBold, colorGreen, day, colorBlue, colorReset)
 				} else if hasEntry {
 					// entry only: green
-					fmt.Printf(" %s%s%2d%s │", colorBold, colorGreen, day, colorReset)
+					line += fmt.Sprintf(" %s%s%2d%s │", colorBold, colorGreen, day, colorReset)
 				} else if hasFiles {
 					// files only: blue with * marker
-					fmt.Printf(" %s%s%2d*%s│", colorBold, colorBlue, day, colorReset)
+					line += fmt.Sprintf(" %s%s%2d*%s│", colorBold, colorBlue, day, colorReset)
 				} else {
-					fmt.Printf(" %2d │", day)
+					line += fmt.Sprintf(" %2d │", day)
 				}
 				day++
 			}
 		}
-		fmt.Println()
+		lines = append(lines, line)
+	}
+	lines = append(lines, "└────┴────┴────┴────┴────┴────┴────┘")
+	return lines
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
 	}
-	fmt.Printf("└────┴────┴────┴────┴────┴────┴────┘\n")
+	return s != ""
 }
 
 func daysIn(month, year int) int {
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseCalendarFlags(t *testing.T) {
+	o, rest, err := parseCalendarFlags([]string{"-1", "-n", "3", "-heat", "-t", "release"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0] != "-1" {
+		t.Fatalf("got rest %v", rest)
+	}
+	if o.months != 3 || !o.heat || o.tag != "release" {
+		t.Fatalf("got %+v", o)
+	}
+
+	for _, x := range [][]string{{"-n"}, {"-n", "0"}, {"-n", "x"}, {"-t"}} {
+		if _, _, err := parseCalendarFlags(x); err == nil {
+			t.Errorf("%v: expected error", x)
+		}
+	}
+}
+
+func TestRenderCalendarWeeks(t *testing.T) {
+	// February 2026 starts on a Sunday and fills exactly four weeks
+	m := &calendarMonth{year: 2026, month: 2}
+	if n := len(renderCalendar(m, 0)); n != 10 {
+		t.Errorf("got %v lines, want 10", n)
+	}
+	if n := len(renderCalendar(m, 6)); n != 12 {
+		t.Errorf("got %v lines with padding, want 12", n)
+	}
+}
@@ -2,36 +2,507 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func getConfig(path string) (map[string]string, error) {
-	configPath := filepath.Join(path, tagebuchMagic)
-	c := make(map[string]string)
+var configCommands = &Options{
+	commands: []string{
+		"list",
+		"get",
+		"set",
+	},
+	descriptions: []string{
+		"list all settings and their values",
+		"print a setting: config get <key>",
+		"change a setting: config set <key> <value>",
+	},
+}
+
+const (
+	configDefaultJournal = "default_journal"
+	configBase           = "base"
+	configEditor         = "editor"
+
+	// sources of a setting
+	sourceUser    = "user"
+	sourceJournal = "journal"
+)
+
+// userConfigPath is the user's own settings file, which applies to every
+// journal unless overridden by its .tagebuch. It is loaded into userConfig
+// at startup.
+var (
+	userConfigPath string
+	userConfig     *config
+)
+
+// config is a journal's settings, read from the user config and then its
+// .tagebuch file as key=value lines. Blank lines and lines starting with #
+// are ignored.
+type config struct {
+	git      bool
+	indexGit bool
+	todoLog  bool
+	editor   string
+	template string
+	encrypt  bool
+
+	serveTLS      bool
+	serveCert     string
+	serveKey      string
+	serveToken    string
+	servePassword string
+	serveSync     time.Duration
+
+	// only in the user config
+	defaultJournal string
+	base           string
+
+	values  map[string]string // as set in a file
+	source  map[string]string // which file each value came from
+	unknown []string          // keys in the file that aren't in configKeys
+}
+
+// configKey documents a setting and how to apply it to a config.
+type configKey struct {
+	name        string
+	def         string
+	description string
+	user        bool // only valid in the user config
+	secret      bool // hidden when listing
+	set         func(c *config, v string) error
+}
+
+// configKeys is every setting a journal may have.
+var configKeys = []configKey{
+	{
+		name:        configGit,
+		def:         "false",
+		description: "pull before reading and push after writing",
+		set:         setBool(func(c *config) *bool { return &c.git }),
+	},
+	{
+		name:        configIndexGit,
+		def:         "false",
+		description: "commit the search index instead of ignoring it",
+		set:         setBool(func(c *config) *bool { return &c.indexGit }),
+	},
+	{
+		name:        configTodoLog,
+		def:         "false",
+		description: "record completed todos in that day's entry",
+		set:         setBool(func(c *config) *bool { return &c.todoLog }),
+	},
+	{
+		name:        configEncrypt,
+		def:         "false",
+		description: "encrypt entries and attached files (see encrypt)",
+		set:         setBool(func(c *config) *bool { return &c.encrypt }),
+	},
+	{
+		name:        configEditor,
+		description: "editor to use instead of $EDITOR",
+		set:         setString(func(c *config) *string { return &c.editor }),
+	},
+	{
+		name:        configTemplate,
+		description: "template for new entries, from the templates directory",
+		set:         setString(func(c *config) *string { return &c.template }),
+	},
+	{
+		name:        configServeTLS,
+		def:         "false",
+		description: "serve HTTPS, with a self-signed certificate unless serve_cert is set",
+		set:         setBool(func(c *config) *bool { return &c.serveTLS }),
+	},
+	{
+		name:        configServeCert,
+		description: "TLS certificate file for serve",
+		set:         setString(func(c *config) *string { return &c.serveCert }),
+	},
+	{
+		name:        configServeKey,
+		description: "TLS private key file for serve",
+		set:         setString(func(c *config) *string { return &c.serveKey }),
+	},
+	{
+		name:        configServeToken,
+		description: "bearer token serve requires of API clients",
+		secret:      true,
+		set:         setString(func(c *config) *string { return &c.serveToken }),
+	},
+	{
+		name:        configServePassword,
+		description: "password serve requires of browsers, with any user name",
+		secret:      true,
+		set:         setString(func(c *config) *string { return &c.servePassword }),
+	},
+	{
+		name:        configServeSync,
+		def:         "5m",
+		description: "how often serve pulls and pushes with git, or 0 for only when asked",
+		set:         setDuration(func(c *config) *time.Duration { return &c.serveSync }),
+	},
+	{
+		name:        configDefaultJournal,
+		description: "journal to use when none is named",
+		user:        true,
+		set:         setString(func(c *config) *string { return &c.defaultJournal }),
+	},
+	{
+		name:        configBase,
+		def:         "~/.tb/",
+		description: "path to journals, unless given with -b",
+		user:        true,
+		set:         setString(func(c *config) *string { return &c.base }),
+	},
+}
+
+func setString(field func(c *config) *string) func(c *config, v string) error {
+	return func(c *config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setDuration(field func(c *config) *time.Duration) func(c *config, v string) error {
+	return func(c *config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("expected a duration such as 5m or 1h: %v", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func setBool(field func(c *config) *bool) func(c *config, v string) error {
+	return func(c *config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("expected true or false: %v", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func findConfigKey(name string) (configKey, bool) {
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, true
+		}
+	}
+	return configKey{}, false
+}
+
+// defaultConfig returns a config with every setting at its default.
+func defaultConfig() *config {
+	c := &config{
+		values: make(map[string]string),
+		source: make(map[string]string),
+	}
+	for _, k := range configKeys {
+		if err := k.set(c, k.def); err != nil {
+			panic(fmt.Sprintf("invalid default for %v: %v", k.name, err))
+		}
+	}
+	return c
+}
 
-	f, err := os.Open(configPath)
+// getConfig returns a journal's settings, layered over the user config.
+func getConfig(path string) (*config, error) {
+	c := defaultConfig()
+	if userConfig != nil {
+		for _, k := range configKeys {
+			v, ok := userConfig.values[k.name]
+			if !ok || k.user {
+				continue
+			}
+			// already validated when loaded
+			k.set(c, v)
+			c.values[k.name] = v
+			c.source[k.name] = sourceUser
+		}
+	}
+
+	f, err := os.Open(filepath.Join(path, tagebuchMagic))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return c, c.parse(f, sourceJournal)
+}
+
+// loadUserConfig reads the user config, which may not exist.
+func loadUserConfig(name string) (*config, error) {
+	c := defaultConfig()
+
+	f, err := os.Open(name)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
 		return nil, err
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	err = c.parse(f, sourceUser)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return c, nil
+}
+
+// parseConfig reads journal settings without the user config.
+func parseConfig(r io.Reader) (*config, error) {
+	c := defaultConfig()
+	return c, c.parse(r, sourceJournal)
+}
+
+// parse applies settings from r over those already in c.
+func (c *config) parse(r io.Reader, source string) error {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		text := strings.TrimSpace(scanner.Text())
-		if text == "" {
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("invalid config item: %v", text)
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+
+		key, ok := findConfigKey(k)
+		if !ok || (key.user && source != sourceUser) {
+			c.unknown = append(c.unknown, k)
+			c.values[k] = v
+			c.source[k] = source
 			continue
 		}
-		kv := strings.Split(text, "=")
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("invalid config item: %v", text)
+		if err := key.set(c, v); err != nil {
+			return fmt.Errorf("invalid config item: %v: %w", text, err)
 		}
-		c[kv[0]] = kv[1]
+		c.values[k] = v
+		c.source[k] = source
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return scanner.Err()
+}
+
+// configFile is the settings file a config command reads and changes,
+// either a journal's .tagebuch or the user config.
+type configFile struct {
+	name    string
+	journal string // empty for the user config
+}
+
+func (f *configFile) load() (*config, error) {
+	if f.journal == "" {
+		return loadUserConfig(f.name)
 	}
-	return c, nil
+	return getConfig(f.journal)
+}
+
+// applies reports whether a setting can be made in this file.
+func (f *configFile) applies(k configKey) bool {
+	return f.journal == "" || !k.user
+}
+
+func configCommand(path string, x []string) error {
+	// only check that this is a journal, so that an invalid setting can
+	// still be fixed
+	pc := filepath.Join(path, tagebuchMagic)
+	_, err := os.Stat(pc)
+	if err != nil {
+		return fmt.Errorf("invalid tagebuch: %v: %v", path, err)
+	}
+
+	return configRun(&configFile{name: pc, journal: path}, x)
+}
+
+// userConfigCommand views or changes the user config.
+func userConfigCommand(x []string) error {
+	if userConfigPath == "" {
+		return fmt.Errorf("no user config directory")
+	}
+	return configRun(&configFile{name: userConfigPath}, x)
+}
+
+func configRun(f *configFile, x []string) error {
+	if len(x) == 0 {
+		return configList(f, x)
+	}
+
+	r, err := Apropos(x[0], configCommands.commands)
+	if err != nil {
+		return err
+	}
+
+	switch r {
+	case "list":
+		return configList(f, x[1:])
+	case "get":
+		return configGet(f, x[1:])
+	case "set":
+		return configSet(f, x[1:])
+	default:
+		return fmt.Errorf("invalid command %v", r)
+	}
+}
+
+func configList(f *configFile, x []string) error {
+	if len(x) != 0 {
+		return fmt.Errorf("trailing commands: %v", x)
+	}
+
+	c, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	o := &Options{}
+	for _, k := range configKeys {
+		if !f.applies(k) {
+			continue
+		}
+		v, ok := c.values[k.name]
+		desc := k.description
+		if !ok {
+			v = k.def
+			desc += " (default)"
+		} else if f.journal != "" && c.source[k.name] == sourceUser {
+			desc += " (user config)"
+		}
+		if ok && k.secret && v != "" {
+			v = "(hidden)"
+		}
+		o.commands = append(o.commands, k.name+"="+v)
+		o.descriptions = append(o.descriptions, desc)
+	}
+	for _, k := range c.unknown {
+		o.commands = append(o.commands, k+"="+c.values[k])
+		o.descriptions = append(o.descriptions, "unknown setting")
+	}
+
+	fmt.Println(o)
+	return nil
+}
+
+func configGet(f *configFile, x []string) error {
+	if len(x) == 0 {
+		return fmt.Errorf("must provide setting name")
+	}
+	if len(x) > 1 {
+		return fmt.Errorf("trailing commands: %v", x[1:])
+	}
+
+	c, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	if v, ok := c.values[x[0]]; ok {
+		fmt.Println(v)
+		return nil
+	}
+	k, ok := findConfigKey(x[0])
+	if !ok || !f.applies(k) {
+		return fmt.Errorf("unknown setting: %v", x[0])
+	}
+	fmt.Println(k.def)
+	return nil
+}
+
+func configSet(f *configFile, x []string) error {
+	if len(x) < 2 {
+		return fmt.Errorf("usage: config set <key> <value>")
+	}
+
+	name := x[0]
+	value := strings.Join(x[1:], " ")
+
+	k, ok := findConfigKey(name)
+	if !ok {
+		return fmt.Errorf("unknown setting: %v", name)
+	}
+	if !f.applies(k) {
+		return fmt.Errorf("%v can only be set in the user config: tb config set %v <value>", name, name)
+	}
+	if err := k.set(defaultConfig(), value); err != nil {
+		return fmt.Errorf("invalid value for %v: %w", name, err)
+	}
+
+	if f.journal == "" {
+		err := os.MkdirAll(filepath.Dir(f.name), 0755)
+		if err != nil {
+			return err
+		}
+		return rewriteConfig(f.name, name, value)
+	}
+
+	unlock, err := lockJournal(f.journal)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = syncPull(f.journal)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	err = rewriteConfig(f.name, name, value)
+	if err != nil {
+		return err
+	}
+
+	err = syncPush(f.journal)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return nil
+}
+
+// rewriteConfig sets name to value in a config file, replacing the setting
+// in place and keeping comments and order. The file may not exist yet.
+func rewriteConfig(file, name, value string) error {
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var lines []string
+	found := false
+	var old []string
+	if text := strings.TrimRight(string(data), "\n"); text != "" {
+		old = strings.Split(text, "\n")
+	}
+	for _, line := range old {
+		text := strings.TrimSpace(line)
+		if k, _, ok := strings.Cut(text, "="); ok && !strings.HasPrefix(text, "#") && strings.TrimSpace(k) == name {
+			if found {
+				// drop duplicates, only the last of which was used
+				continue
+			}
+			line = name + "=" + value
+			found = true
+		}
+		lines = append(lines, line)
+	}
+	if !found {
+		lines = append(lines, name+"="+value)
+	}
+
+	return writeFileAtomic(file, strings.NewReader(strings.Join(lines, "\n")+"\n"))
 }
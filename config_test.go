@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	input := `# sync with the remote
+git = true
+
+todo_log=false
+mystery=a=b
+`
+	c, err := parseConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.git || c.todoLog || c.indexGit {
+		t.Fatal("invalid values", c)
+	}
+	if len(c.unknown) != 1 || c.unknown[0] != "mystery" || c.values["mystery"] != "a=b" {
+		t.Fatal("invalid unknown keys", c.unknown, c.values)
+	}
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	_, err := parseConfig(strings.NewReader("git=maybe\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	_, err = parseConfig(strings.NewReader("git\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseConfigDuration(t *testing.T) {
+	c, err := parseConfig(strings.NewReader("serve_sync=90s\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.serveSync != 90*time.Second {
+		t.Fatal("invalid value", c.serveSync)
+	}
+
+	c, err = parseConfig(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.serveSync != 5*time.Minute {
+		t.Fatal("invalid default", c.serveSync)
+	}
+
+	for _, v := range []string{"soon", "-1m", "5"} {
+		if _, err := parseConfig(strings.NewReader("serve_sync=" + v + "\n")); err == nil {
+			t.Errorf("%v: expected error", v)
+		}
+	}
+}
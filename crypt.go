@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	gosync "sync" // sync is the sync command
+	"sync/atomic"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// configEncrypt encrypts entries, attached files and the search index
+	// when true.
+	configEncrypt = "encrypt"
+
+	// tagebuchKey holds what's needed to derive the key from the
+	// passphrase, but not the key itself.
+	tagebuchKey = "key"
+
+	// envPassphrase is read instead of prompting, for scripts.
+	envPassphrase = "TB_PASSPHRASE"
+
+	keyVersion = 1
+)
+
+// cryptMagic starts every encrypted file, followed by a nonce and the
+// sealed content.
+var cryptMagic = []byte("tbenc1\n")
+
+var encryptCommands = &Options{
+	commands: []string{
+		"enable",
+		"disable",
+	},
+	descriptions: []string{
+		"encrypt all entries and attached files, and any written from now on",
+		"decrypt all entries and attached files",
+	},
+}
+
+type keyFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Check   []byte `json:"check"` // cryptMagic sealed with the key, to detect a wrong passphrase
+}
+
+// journalKeys caches each journal's key for the life of the process, so the
+// passphrase is asked for at most once, even by concurrent requests.
+var (
+	journalKeys   = make(map[string]*[32]byte)
+	journalKeysMu gosync.Mutex
+)
+
+// noPassphrasePrompt is set by the server once it is running, as there is no
+// one at the terminal to answer. Keys must have been loaded before then, or
+// come from $TB_PASSPHRASE.
+var noPassphrasePrompt atomic.Bool
+
+// journalKey returns the journal's key, asking for the passphrase. A new
+// key is created if the journal doesn't have one.
+func journalKey(path string) (*[32]byte, error) {
+	journalKeysMu.Lock()
+	defer journalKeysMu.Unlock()
+
+	if k, ok := journalKeys[path]; ok {
+		return k, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, tagebuchKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return newJournalKey(path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var kf keyFile
+	err = json.Unmarshal(data, &kf)
+	if err != nil || kf.Version != keyVersion {
+		return nil, fmt.Errorf("invalid key file: %v", filepath.Join(path, tagebuchKey))
+	}
+
+	pass, err := readPassphrase("passphrase for " + filepath.Base(path) + ": ")
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := deriveKey(pass, &kf)
+	if err != nil {
+		return nil, err
+	}
+	check, err := openBox(k, kf.Check)
+	if err != nil || !bytes.Equal(check, cryptMagic) {
+		return nil, fmt.Errorf("wrong passphrase")
+	}
+
+	journalKeys[path] = k
+	return k, nil
+}
+
+func newJournalKey(path string) (*[32]byte, error) {
+	pass, err := readPassphrase("new passphrase for " + filepath.Base(path) + ": ")
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	if os.Getenv(envPassphrase) == "" {
+		again, err := readPassphrase("repeat passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, fmt.Errorf("passphrases don't match")
+		}
+	}
+
+	kf := &keyFile{
+		Version: keyVersion,
+		Salt:    make([]byte, 32),
+		N:       1 << 15,
+		R:       8,
+		P:       1,
+	}
+	if _, err := rand.Read(kf.Salt); err != nil {
+		return nil, err
+	}
+
+	k, err := deriveKey(pass, kf)
+	if err != nil {
+		return nil, err
+	}
+	kf.Check, err = sealBox(k, cryptMagic)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(kf, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	err = writeFileAtomic(filepath.Join(path, tagebuchKey), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	journalKeys[path] = k
+	return k, nil
+}
+
+func deriveKey(pass []byte, kf *keyFile) (*[32]byte, error) {
+	b, err := scrypt.Key(pass, kf.Salt, kf.N, kf.R, kf.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	k := new([32]byte)
+	copy(k[:], b)
+	return k, nil
+}
+
+// readPassphrase returns $TB_PASSPHRASE, or asks on the terminal unless
+// serving.
+func readPassphrase(prompt string) ([]byte, error) {
+	if p := os.Getenv(envPassphrase); p != "" {
+		return []byte(p), nil
+	}
+
+	if noPassphrasePrompt.Load() {
+		return nil, fmt.Errorf("journal is encrypted: its passphrase wasn't given when the server started")
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("journal is encrypted: set $%v or run from a terminal", envPassphrase)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return p, err
+}
+
+func sealBox(k *[32]byte, plain []byte) ([]byte, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	out := append(bytes.Clone(cryptMagic), nonce[:]...)
+	return secretbox.Seal(out, plain, &nonce, k), nil
+}
+
+func openBox(k *[32]byte, data []byte) ([]byte, error) {
+	if !isEncrypted(data) || len(data) < len(cryptMagic)+24 {
+		return nil, fmt.Errorf("not an encrypted file")
+	}
+	data = data[len(cryptMagic):]
+
+	var nonce [24]byte
+	copy(nonce[:], data)
+	plain, ok := secretbox.Open(nil, data[24:], &nonce, k)
+	if !ok {
+		return nil, fmt.Errorf("decryption failed")
+	}
+	return plain, nil
+}
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, cryptMagic)
+}
+
+func encryptEnabled(path string) (bool, error) {
+	c, err := getConfig(path)
+	if err != nil {
+		return false, err
+	}
+	return c.encrypt, nil
+}
+
+// readJournalFile reads a file in the journal at path, decrypting it if it
+// is encrypted.
+func readJournalFile(path, name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil || !isEncrypted(data) {
+		return data, err
+	}
+
+	k, err := journalKey(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := openBox(k, data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return plain, nil
+}
+
+// writeJournalFile atomically writes a file in the journal at path,
+// encrypting it if the journal is encrypted. Empty files are left as they
+// are, as an empty entry is no entry.
+func writeJournalFile(path, name string, data []byte) error {
+	enc, err := encryptEnabled(path)
+	if err != nil {
+		return err
+	}
+
+	if enc && len(data) > 0 {
+		k, err := journalKey(path)
+		if err != nil {
+			return err
+		}
+		data, err = sealBox(k, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(name, bytes.NewReader(data))
+}
+
+// privateTemp writes data to a new temporary file only the user can read,
+// in memory where possible, for editing a copy of an entry, which may have
+// been decrypted. The returned function overwrites and removes it.
+func privateTemp(pattern string, data []byte) (string, func(), error) {
+	dir := ""
+	if runtime.GOOS == "linux" {
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			dir = "/dev/shm"
+		}
+	}
+
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	name := f.Name()
+	remove := func() {
+		if f, err := os.OpenFile(name, os.O_WRONLY, 0); err == nil {
+			if info, err := f.Stat(); err == nil {
+				io.CopyN(f, zeroReader{}, info.Size())
+				f.Sync()
+			}
+			f.Close()
+		}
+		os.Remove(name)
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		remove()
+		return "", nil, err
+	}
+	return name, remove, nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func encrypt(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	if len(x) == 0 {
+		return fmt.Errorf("command required. Options are:\n%v", encryptCommands)
+	}
+
+	r, err := Apropos(x[0], encryptCommands.commands)
+	if err != nil {
+		return err
+	}
+	if len(x) > 1 {
+		return fmt.Errorf("trailing commands: %v", x[1:])
+	}
+
+	switch r {
+	case "enable":
+		return encryptJournal(path, true)
+	case "disable":
+		return encryptJournal(path, false)
+	default:
+		return fmt.Errorf("invalid command %v", r)
+	}
+}
+
+// encryptJournal encrypts or decrypts every entry and attached file, along
+// with the search index, and changes the encrypt setting to match. Files
+// already as wanted are left alone, so an interrupted run can be repeated.
+func encryptJournal(path string, enable bool) error {
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	k, err := journalKey(path)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for date, day := range scanDays(path) {
+		names = append(names, filepath.Join(path, date, entryName))
+		for _, f := range day.Files {
+			names = append(names, filepath.Join(path, date, f))
+		}
+	}
+	if indexExists(path) {
+		names = append(names, filepath.Join(path, tagebuchIndex))
+	}
+
+	n := 0
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if len(data) == 0 || isEncrypted(data) == enable {
+			continue
+		}
+
+		if enable {
+			data, err = sealBox(k, data)
+		} else {
+			data, err = openBox(k, data)
+		}
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		err = writeFileAtomic(name, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		n++
+	}
+
+	err = rewriteConfig(filepath.Join(path, tagebuchMagic), configEncrypt, fmt.Sprint(enable))
+	if err != nil {
+		return err
+	}
+	if enable {
+		fmt.Printf("encrypted %v files\n", n)
+		if g, err := useGit(path); err == nil && g {
+			fmt.Fprintln(os.Stderr, "warning: earlier plaintext versions of entries, attached files and the search index remain in the git history, locally and on the remote")
+		}
+	} else {
+		fmt.Printf("decrypted %v files\n", n)
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealBox(t *testing.T) {
+	k := new([32]byte)
+	k[0] = 1
+
+	data, err := sealBox(k, []byte("dear diary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncrypted(data) || bytes.Contains(data, []byte("diary")) {
+		t.Fatalf("not encrypted: %q", data)
+	}
+
+	plain, err := openBox(k, data)
+	if err != nil || string(plain) != "dear diary" {
+		t.Errorf("got %q, %v", plain, err)
+	}
+
+	other := new([32]byte)
+	if _, err := openBox(other, data); err == nil {
+		t.Error("opened with the wrong key")
+	}
+	if _, err := openBox(k, []byte("dear diary")); err == nil {
+		t.Error("opened plaintext")
+	}
+}
+
+func TestReadPassphraseServing(t *testing.T) {
+	t.Setenv(envPassphrase, "")
+	noPassphrasePrompt.Store(true)
+	defer noPassphrasePrompt.Store(false)
+
+	if _, err := readPassphrase("passphrase: "); err == nil {
+		t.Fatal("expected error")
+	}
+
+	t.Setenv(envPassphrase, "secret")
+	p, err := readPassphrase("passphrase: ")
+	if err != nil || string(p) != "secret" {
+		t.Fatal("invalid passphrase", string(p), err)
+	}
+}
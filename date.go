@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateHelp = `Dates may be given as:
+  today, yesterday, tomorrow
+  year/month/day or year-month-day (e.g. 2026/1/6, 2026-01-06)
+  -N or +N          : N days before or after today
+  N days ago        : also weeks, months and years
+  in N days         : also weeks, months and years
+  monday            : the most recent monday (including today)
+  last/next monday  : the monday before or after today
+  <alias>           : a named alias (see alias)`
+
+const rangeHelp = `Ranges may be given as:
+  <date>..<date>    : inclusive range (e.g. 2026/1/1..2026/1/31)
+  year/month        : a whole month (e.g. 2026/1)
+  year              : a whole year (e.g. 2026)
+  this/last week    : also months and years`
+
+// maxDateWords is the largest number of arguments a single date expression
+// may span, e.g. "2 weeks ago".
+const maxDateWords = 3
+
+var dateWords = []string{
+	"today",
+	"yesterday",
+	"tomorrow",
+	"sunday",
+	"monday",
+	"tuesday",
+	"wednesday",
+	"thursday",
+	"friday",
+	"saturday",
+}
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// parseDateArg resolves the date at the start of x, which may span several
+// arguments, and returns it along with the remaining arguments. Aliases are
+// consulted only if no built-in form matches, so alias add refuses names that
+// would be read as one (see isBuiltinDate).
+func parseDateArg(path string, x []string) (time.Time, []string, error) {
+	if len(x) == 0 {
+		return time.Time{}, nil, fmt.Errorf("date required\n%v", dateHelp)
+	}
+
+	now := time.Now()
+	n := min(len(x), maxDateWords)
+
+	var parseErr error
+	for i := n; i > 0; i-- {
+		t, err := parseDate(strings.Join(x[:i], " "), now)
+		if err == nil {
+			return t, x[i:], nil
+		}
+		parseErr = err
+	}
+
+	a, err := aliasLoad(path)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	for i := n; i > 0; i-- {
+		d, ok := a.a[strings.Join(x[:i], " ")]
+		if !ok {
+			continue
+		}
+		t, err := parseDate(d, now)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("alias %v: %w", x[0], err)
+		}
+		return t, x[i:], nil
+	}
+
+	return time.Time{}, nil, fmt.Errorf("%w\n%v", parseErr, dateHelp)
+}
+
+// parseRangeArg resolves the date range at the start of x and returns the
+// first and last day (inclusive) along with the remaining arguments. A single
+// date is returned as a range of one day.
+func parseRangeArg(path string, x []string) (time.Time, time.Time, []string, error) {
+	if len(x) == 0 {
+		return time.Time{}, time.Time{}, nil, fmt.Errorf("date required\n%v\n%v", dateHelp, rangeHelp)
+	}
+
+	if a, b, ok := strings.Cut(x[0], ".."); ok {
+		start, err := parseDateString(path, a)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, err
+		}
+		end, err := parseDateString(path, b)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, err
+		}
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, nil, fmt.Errorf("invalid range: %v is before %v", dateString(end), dateString(start))
+		}
+		return start, end, x[1:], nil
+	}
+
+	now := time.Now()
+	for i := min(len(x), 2); i > 0; i-- {
+		start, end, err := parsePeriod(strings.Join(x[:i], " "), now)
+		if err == nil {
+			return start, end, x[i:], nil
+		}
+	}
+
+	t, rest, err := parseDateArg(path, x)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, fmt.Errorf("%w\n%v", err, rangeHelp)
+	}
+	return t, t, rest, nil
+}
+
+// isBuiltinDate reports whether s, or the first words of it, would be read as
+// a date or range without consulting aliases.
+func isBuiltinDate(s string) bool {
+	now := time.Now()
+	if _, _, err := parsePeriod(s, now); err == nil {
+		return true
+	}
+	fields := strings.Fields(s)
+	for i := min(len(fields), maxDateWords); i > 0; i-- {
+		if _, err := parseDate(strings.Join(fields[:i], " "), now); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDateString resolves a single date expression, which may contain
+// spaces, requiring that all of it is consumed.
+func parseDateString(path, s string) (time.Time, error) {
+	t, rest, err := parseDateArg(path, strings.Fields(s))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(rest) != 0 {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDate, s)
+	}
+	return t, nil
+}
+
+// parsePeriod parses a named period relative to now and returns its first
+// and last day.
+func parsePeriod(s string, now time.Time) (time.Time, time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		// only a full year, so that a count such as the 2 in "2 weeks ago"
+		// isn't taken for one
+		f := strings.Split(s, "/")
+		if len(f) > 2 || len(f[0]) != 4 || !isDigits(f[0]) {
+			break
+		}
+		year, _ := strconv.Atoi(f[0])
+		if len(f) == 1 {
+			start := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+			return start, start.AddDate(1, 0, -1), nil
+		}
+		month, err := strconv.Atoi(f[1])
+		if err != nil || month < 1 || month > 12 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid month: %v", f[1])
+		}
+		start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, -1), nil
+	case 2:
+		var offset int
+		switch fields[0] {
+		case "this":
+		case "last":
+			offset = -1
+		default:
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid range: %v", s)
+		}
+
+		switch fields[1] {
+		case "week":
+			// weeks start on sunday, matching the calendar
+			start := today.AddDate(0, 0, -int(today.Weekday())+7*offset)
+			return start, start.AddDate(0, 0, 6), nil
+		case "month":
+			start := time.Date(today.Year(), today.Month()+time.Month(offset), 1, 0, 0, 0, 0, today.Location())
+			return start, start.AddDate(0, 1, -1), nil
+		case "year":
+			start := time.Date(today.Year()+offset, 1, 1, 0, 0, 0, 0, today.Location())
+			return start, start.AddDate(1, 0, -1), nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid range: %v", s)
+}
+
+// parseDate parses a single date expression relative to now. It does not
+// consult aliases.
+func parseDate(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if s == "" {
+		return time.Time{}, fmt.Errorf("%w: empty", ErrInvalidDate)
+	}
+
+	// relative offset in days: -3, +2
+	if s[0] == '-' || s[0] == '+' {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDate, s)
+		}
+		return today.AddDate(0, 0, n), nil
+	}
+
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		// year/month/day or year-month-day
+		for _, sep := range []string{"/", "-"} {
+			if f := strings.Split(s, sep); len(f) == 3 {
+				return parseYMD(f)
+			}
+		}
+
+		r, err := Apropos(fields[0], dateWords)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v: %w", ErrInvalidDate, s, err)
+		}
+		switch r {
+		case "today":
+			return today, nil
+		case "yesterday":
+			return today.AddDate(0, 0, -1), nil
+		case "tomorrow":
+			return today.AddDate(0, 0, 1), nil
+		}
+		wd, _ := parseWeekday(r)
+		return weekdayBefore(today.AddDate(0, 0, 1), wd), nil
+	case 2:
+		wd, err := parseWeekday(fields[1])
+		if err != nil {
+			break
+		}
+		switch fields[0] {
+		case "last":
+			return weekdayBefore(today, wd), nil
+		case "next":
+			return weekdayAfter(today, wd), nil
+		}
+	case 3:
+		if fields[2] == "ago" {
+			return offsetDate(today, fields[0], fields[1], -1)
+		}
+		if fields[0] == "in" {
+			return offsetDate(today, fields[1], fields[2], 1)
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDate, s)
+}
+
+// parseYMD parses and validates year, month and day fields.
+func parseYMD(f []string) (time.Time, error) {
+	year, err := strconv.Atoi(f[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid year: %v: %v", f[0], err)
+	}
+	month, err := strconv.Atoi(f[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month: %v: %v", f[1], err)
+	}
+	day, err := strconv.Atoi(f[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day: %v: %v", f[2], err)
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	valid := t.Year() == year && t.Month() == time.Month(month) && t.Day() == day
+	if !valid {
+		return time.Time{}, fmt.Errorf("%w: %v/%v/%v", ErrInvalidDate, year, month, day)
+	}
+	return t, nil
+}
+
+// parseWeekday parses a weekday name, allowing unambiguous prefixes.
+func parseWeekday(s string) (time.Weekday, error) {
+	r, err := Apropos(s, dateWords[3:])
+	if err != nil {
+		return 0, err
+	}
+	for i, v := range dateWords[3:] {
+		if v == r {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday: %v", s)
+}
+
+// weekdayBefore returns the latest day strictly before t falling on wd.
+func weekdayBefore(t time.Time, wd time.Weekday) time.Time {
+	diff := (int(t.Weekday()) - int(wd) + 6) % 7
+	return t.AddDate(0, 0, -diff-1)
+}
+
+// weekdayAfter returns the earliest day strictly after t falling on wd.
+func weekdayAfter(t time.Time, wd time.Weekday) time.Time {
+	diff := (int(wd) - int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, diff+1)
+}
+
+// offsetDate moves t by count units in direction sign (1 or -1).
+func offsetDate(t time.Time, count, unit string, sign int) (time.Time, error) {
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("%w: invalid count: %v", ErrInvalidDate, count)
+	}
+	n *= sign
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "day":
+		return t.AddDate(0, 0, n), nil
+	case "week":
+		return t.AddDate(0, 0, 7*n), nil
+	case "month":
+		return t.AddDate(0, n, 0), nil
+	case "year":
+		return t.AddDate(n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid unit: %v", ErrInvalidDate, unit)
+}
+
+// dateString formats t as year/month/day, the layout used on disk.
+func dateString(t time.Time) string {
+	return fmt.Sprintf("%d/%d/%d", t.Year(), int(t.Month()), t.Day())
+}
+
+// dayPath returns the directory for day t within the journal at path.
+func dayPath(path string, t time.Time) string {
+	return filepath.Join(path, dateString(t))
+}
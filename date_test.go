@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 1, 7, 15, 4, 5, 0, time.Local)
+
+	tests := map[string]string{
+		"today":          "2026/1/7",
+		"tod":            "2026/1/7",
+		"yesterday":      "2026/1/6",
+		"tomorrow":       "2026/1/8",
+		"2026/1/6":       "2026/1/6",
+		"2026-01-06":     "2026/1/6",
+		"-3":             "2026/1/4",
+		"+2":             "2026/1/9",
+		"wednesday":      "2026/1/7",
+		"mon":            "2026/1/5",
+		"last wednesday": "2025/12/31",
+		"last friday":    "2026/1/2",
+		"next monday":    "2026/1/12",
+		"2 weeks ago":    "2025/12/24",
+		"1 month ago":    "2025/12/7",
+		"in 3 days":      "2026/1/10",
+	}
+
+	for in, want := range tests {
+		got, err := parseDate(in, now)
+		if err != nil {
+			t.Errorf("%v: %v", in, err)
+			continue
+		}
+		if dateString(got) != want {
+			t.Errorf("%v: got %v, want %v", in, dateString(got), want)
+		}
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	now := time.Date(2026, 1, 7, 0, 0, 0, 0, time.Local)
+
+	for _, in := range []string{"", "2026/2/30", "t", "last", "2 fortnights ago", "foo"} {
+		_, err := parseDate(in, now)
+		if err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+
+	_, err := parseDate("2026/2/30", now)
+	if !errors.Is(err, ErrInvalidDate) {
+		t.Fatal("invalid or missing error", err)
+	}
+}
+
+func TestIsBuiltinDate(t *testing.T) {
+	for _, in := range []string{"today", "mon", "w", "fri", "sat", "fri party", "2 weeks ago", "2026/1/6", "2026", "last week"} {
+		if !isBuiltinDate(in) {
+			t.Errorf("%q: expected a date", in)
+		}
+	}
+	for _, in := range []string{"great thoughts", "birthday", "launch", "xmas"} {
+		if isBuiltinDate(in) {
+			t.Errorf("%q: not a date", in)
+		}
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 1, 7, 15, 4, 5, 0, time.Local)
+
+	tests := map[string][2]string{
+		"2026/2":     {"2026/2/1", "2026/2/28"},
+		"2025":       {"2025/1/1", "2025/12/31"},
+		"this week":  {"2026/1/4", "2026/1/10"},
+		"last week":  {"2025/12/28", "2026/1/3"},
+		"this month": {"2026/1/1", "2026/1/31"},
+		"last month": {"2025/12/1", "2025/12/31"},
+		"last year":  {"2025/1/1", "2025/12/31"},
+	}
+
+	for in, want := range tests {
+		start, end, err := parsePeriod(in, now)
+		if err != nil {
+			t.Errorf("%v: %v", in, err)
+			continue
+		}
+		if dateString(start) != want[0] || dateString(end) != want[1] {
+			t.Errorf("%v: got %v..%v, want %v..%v", in, dateString(start), dateString(end), want[0], want[1])
+		}
+	}
+
+	for _, in := range []string{"-3", "2", "12/5", "2026/1/6", "2026/13", "next week", "today"} {
+		if _, _, err := parsePeriod(in, now); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+// a count, as in "2 weeks ago", isn't taken for a year, so that a range of
+// one relative day falls through to parseDate
+func TestParseRangeRelative(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 1, 7, 15, 4, 5, 0, time.Local)
+
+	tests := map[string]string{
+		"2 weeks ago": "2025/12/24",
+		"3 days ago":  "2026/1/4",
+		"1 month ago": "2025/12/7",
+		"in 2 days":   "2026/1/9",
+	}
+
+	for in, want := range tests {
+		f := strings.Fields(in)
+		for i := min(len(f), 2); i > 0; i-- {
+			if _, _, err := parsePeriod(strings.Join(f[:i], " "), now); err == nil {
+				t.Errorf("%v: %v taken for a period", in, f[:i])
+			}
+		}
+		got, err := parseDate(in, now)
+		if err != nil {
+			t.Errorf("%v: %v", in, err)
+			continue
+		}
+		if dateString(got) != want {
+			t.Errorf("%v: got %v, want %v", in, dateString(got), want)
+		}
+	}
+}
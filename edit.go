@@ -1,12 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -23,10 +25,7 @@ var editCommands = &Options{
 	},
 }
 
-const (
-	dateHelp  = "year/month/day : Specific date"
-	entryName = "entry"
-)
+const entryName = "entry"
 
 func edit(path string, x []string) error {
 	err := validate(path)
@@ -38,103 +37,249 @@ func edit(path string, x []string) error {
 		return fmt.Errorf("command required. Options are:\n%v\n%v", editCommands, dateHelp)
 	}
 
-	var year, month, day int
+	t, rest, err := parseDateArg(path, x)
+	if err != nil {
+		return err
+	}
 
-	// check for a specific date first
-	if f := strings.Split(x[0], "/"); len(f) == 3 {
-		year, err = strconv.Atoi(f[0])
-		if err != nil {
-			return fmt.Errorf("invalid year: %v: %v", f[0], err)
-		}
-		month, err = strconv.Atoi(f[1])
-		if err != nil {
-			return fmt.Errorf("invalid month: %v: %v", f[1], err)
-		}
-		day, err = strconv.Atoi(f[2])
-		if err != nil {
-			return fmt.Errorf("invalid day: %v: %v", f[2], err)
+	// flags follow the date, as dates such as -1 look like flags
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fTemplate := fs.String("template", "", "template for a new entry")
+
+	err = fs.Parse(rest)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("trailing commands: %v", fs.Args())
+	}
+
+	return editDate(path, t, *fTemplate)
+}
+
+// editDate opens the entry for day t in the editor. A new entry starts from
+// the named template, or the journal's template setting. The entry is edited
+// as a copy, decrypted if need be, and the journal isn't locked while
+// editing, so an entry that changes meanwhile, such as by a note or the web
+// UI, isn't overwritten and the edited copy is kept.
+func editDate(path string, t time.Time, tmpl string) error {
+	editor, err := getEditor(path)
+	if err != nil {
+		return err
+	}
+
+	datePath := dayPath(path, t)
+	filename := filepath.Join(datePath, entryName)
+
+	current, initial, before, err := editStart(path, t, tmpl)
+	if err != nil {
+		return err
+	}
+
+	editFile, remove, err := privateTemp("tb-entry-*", current)
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			remove()
 		}
+	}()
+
+	err = runEditor(editor, editFile)
+	if err != nil {
+		return err
+	}
+
+	keep, err = editFinish(path, filename, editFile, current, initial, before)
+	if err != nil {
+		return err
+	}
+
+	err = indexUpdateDay(path, datePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return nil
+}
+
+// editStart reads the entry for day t to edit, under the journal lock,
+// starting it from a template if there is none. It returns the entry's text,
+// the template text if one was applied, and the file as it was on disk.
+func editStart(path string, t time.Time, tmpl string) ([]byte, []byte, []byte, error) {
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer unlock()
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-	} else {
-		r, err := Apropos(x[0], editCommands.commands)
+	filename := filepath.Join(dayPath(path, t), entryName)
+
+	before, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, err
+	}
+	current, err := readJournalFile(path, filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil, err
+	}
+
+	var initial []byte
+	if len(current) == 0 {
+		name, err := templateName(path, tmpl)
 		if err != nil {
-			// try alias lookup
-			aliasDate, aliasErr := aliasLookup(path, x[0])
-			if aliasErr != nil {
-				return aliasErr
-			}
-			if aliasDate != "" {
-				parts := strings.Split(aliasDate, "/")
-				year, _ = strconv.Atoi(parts[0])
-				month, _ = strconv.Atoi(parts[1])
-				day, _ = strconv.Atoi(parts[2])
-			} else {
-				return fmt.Errorf("%w\n%v", err, dateHelp)
-			}
-		} else {
-			when := time.Now()
-			switch r {
-			case "today":
-			case "yesterday":
-				when = when.Add(-24 * time.Hour)
-			case "tomorrow":
-				when = when.Add(24 * time.Hour)
+			return nil, nil, nil, err
+		}
+		if name != "" {
+			initial, err = renderTemplate(path, name, t)
+			if err != nil {
+				return nil, nil, nil, err
 			}
-			year = when.Year()
-			month = int(when.Month())
-			day = when.Day()
+			current = initial
 		}
+	} else if tmpl != "" {
+		fmt.Fprintf(os.Stderr, "entry for %v already exists, not applying template\n", dateString(t))
 	}
 
-	// validate
-	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	valid := t.Year() == year && t.Month() == time.Month(month) && t.Day() == day
-	if !valid {
-		return fmt.Errorf("invalid date: %v/%v/%v", year, month, day)
+	return current, initial, before, nil
+}
+
+// editFinish saves the edited copy at editFile as the entry at filename, under
+// the journal lock, unless the entry changed on disk since editStart. An
+// untouched template is dropped. It returns true if the edited copy must be
+// kept because it wasn't saved.
+func editFinish(path, filename, editFile string, current, initial, before []byte) (bool, error) {
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(editFile)
+	if err != nil {
+		return false, err
+	}
+	// an untouched template isn't an entry
+	if initial != nil && bytes.Equal(data, initial) {
+		data = nil
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+	// nothing to save, including an untouched template for a new entry
+	if bytes.Equal(data, current) || (data == nil && len(before) == 0) {
+		return false, nil
+	}
+	after, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if !bytes.Equal(before, after) {
+		return true, fmt.Errorf("entry changed while editing, no changes saved. Edited copy is in %v", editFile)
+	}
 
-	return editDate(path, datePath, x[1:])
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return false, err
+	}
+	return false, writeJournalFile(path, filename, data)
 }
 
-func editDate(path, datePath string, x []string) error {
-	if len(x) != 0 {
-		return fmt.Errorf("trailing commands: %v", x)
+// getEditor returns the journal's editor setting, or $EDITOR.
+func getEditor(path string) (string, error) {
+	c, err := getConfig(path)
+	if err != nil {
+		return "", err
+	}
+	if c.editor != "" {
+		return c.editor, nil
 	}
 
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
-		return fmt.Errorf("$EDITOR not set")
+		return "", fmt.Errorf("$EDITOR not set")
+	}
+	return editor, nil
+}
+
+// runEditor opens filename in editor and waits for it to exit.
+func runEditor(editor, filename string) error {
+	cmd := exec.Command(editor, filename)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Env = os.Environ()
+	return cmd.Run()
+}
+
+// appendEntry adds a line to the end of a day's entry, creating it if
+// needed. It does not sync.
+func appendEntry(path, datePath, line string) error {
+	err := os.MkdirAll(datePath, 0755)
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(datePath, entryName)
+	data, err := readJournalFile(path, filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// keep the new line separate from any unterminated last line
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
 	}
+	data = append(data, line+"\n"...)
 
-	err := syncPull(path)
+	err = writeJournalFile(path, filename, data)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		return err
 	}
 
-	err = os.MkdirAll(datePath, 0755)
+	return indexUpdateDay(path, datePath)
+}
+
+// entrySave replaces the entry for day t with data, as edited elsewhere than
+// in the editor, such as the web UI.
+func entrySave(path string, t time.Time, data []byte) error {
+	unlock, err := lockJournal(path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	filename := filepath.Join(datePath, entryName)
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	datePath := dayPath(path, t)
+	err = os.MkdirAll(datePath, 0755)
 	if err != nil {
 		return err
 	}
-	f.Close()
 
-	cmd := exec.Command(editor, filename)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Env = os.Environ()
-	err = cmd.Run()
+	err = writeJournalFile(path, filepath.Join(datePath, entryName), data)
 	if err != nil {
 		return err
 	}
 
+	err = indexUpdateDay(path, datePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
 	err = syncPush(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
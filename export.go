@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportJournal is everything exported from a journal.
+type exportJournal struct {
+	Journal string            `json:"journal"`
+	Entries []*exportDay      `json:"entries"`
+	Aliases map[string]string `json:"aliases"`
+	Todos   []*todoItem       `json:"todos"`
+	Done    []*todoItem       `json:"done"`
+}
+
+type exportDay struct {
+	Date    string   `json:"date"` // year/month/day
+	Weekday string   `json:"weekday"`
+	Text    string   `json:"text"`
+	Files   []string `json:"files,omitempty"`
+
+	t time.Time
+}
+
+func export(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fFormat := fs.String("format", "md", "md, html or json")
+	fRange := fs.String("range", "", "only export entries in a date range")
+	fOut := fs.String("o", "", "output file, or directory for html")
+
+	err = fs.Parse(x)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("trailing commands: %v", fs.Args())
+	}
+
+	var start, end time.Time
+	if *fRange != "" {
+		var rest []string
+		start, end, rest, err = parseRangeArg(path, strings.Fields(*fRange))
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("invalid range: %v", *fRange)
+		}
+	}
+
+	switch *fFormat {
+	case "md", "json":
+	case "html":
+		if *fOut == "" {
+			return fmt.Errorf("html export requires an output directory: -o <dir>")
+		}
+	default:
+		return fmt.Errorf("invalid format: %v. Formats are md, html and json", *fFormat)
+	}
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	j, err := exportLoad(path, start, end)
+	if err != nil {
+		return err
+	}
+
+	if *fFormat == "html" {
+		return exportSite(path, j, *fOut)
+	}
+
+	if *fOut == "" {
+		return exportWrite(os.Stdout, j, *fFormat)
+	}
+
+	f, err := os.Create(*fOut)
+	if err != nil {
+		return err
+	}
+	err = exportWrite(f, j, *fFormat)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportWrite writes the journal as a single md or json document.
+func exportWrite(w io.Writer, j *exportJournal, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(j)
+	}
+	return exportMarkdown(w, j)
+}
+
+// exportLoad reads the journal's days with entries or attached files between
+// start and end (inclusive), or all of them if start is zero, along with its
+// aliases and todos. Completed todos are limited to the range too.
+func exportLoad(path string, start, end time.Time) (*exportJournal, error) {
+	j := &exportJournal{Journal: filepath.Base(path)}
+
+	inRange := func(t time.Time) bool {
+		return start.IsZero() || !(t.Before(start) || t.After(end))
+	}
+
+	now := time.Now()
+	for _, e := range dayDates(path) {
+		t, err := parseDate(e, now)
+		if err != nil || !inRange(t) {
+			continue
+		}
+
+		// a day may have attached files but no entry
+		datePath := dayPath(path, t)
+		text, err := readJournalFile(path, filepath.Join(datePath, entryName))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		files, err := listFilesInDay(datePath)
+		if err != nil {
+			return nil, err
+		}
+
+		j.Entries = append(j.Entries, &exportDay{
+			Date:    e,
+			Weekday: t.Weekday().String(),
+			Text:    string(text),
+			Files:   files,
+			t:       t,
+		})
+	}
+
+	a, err := aliasLoad(path)
+	if err != nil {
+		return nil, err
+	}
+	j.Aliases = a.a
+
+	t, err := todoRead(path, tagebuchTodo)
+	if err == nil {
+		j.Todos = t.t
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	d, err := doneLoad(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range d.t {
+		if inRange(v.completed) {
+			j.Done = append(j.Done, v)
+		}
+	}
+
+	return j, nil
+}
+
+// sortedAliases returns alias names in order.
+func (j *exportJournal) sortedAliases() []string {
+	var names []string
+	for k := range j.Aliases {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exportMarkdown writes the journal as a single markdown document. Attached
+// files are listed by their path within the journal.
+func exportMarkdown(w io.Writer, j *exportJournal) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %v\n", j.Journal)
+
+	for _, e := range j.Entries {
+		fmt.Fprintf(&b, "\n## %v %v\n", e.Date, e.Weekday)
+		if text := strings.TrimRight(e.Text, "\n"); text != "" {
+			b.WriteString("\n" + text + "\n")
+		}
+		if len(e.Files) > 0 {
+			b.WriteString("\nFiles:\n\n")
+			for _, f := range e.Files {
+				fmt.Fprintf(&b, "- %v/%v\n", e.Date, f)
+			}
+		}
+	}
+
+	if len(j.Aliases) > 0 {
+		b.WriteString("\n## Aliases\n\n")
+		for _, k := range j.sortedAliases() {
+			fmt.Fprintf(&b, "- %v: %v\n", k, j.Aliases[k])
+		}
+	}
+
+	if len(j.Todos) > 0 {
+		b.WriteString("\n## Todos\n\n")
+		for _, v := range j.Todos {
+			fmt.Fprintf(&b, "- [ ] %v\n", v.display())
+		}
+	}
+
+	if len(j.Done) > 0 {
+		b.WriteString("\n## Done\n\n")
+		for _, v := range j.Done {
+			fmt.Fprintf(&b, "- [x] %v %v\n", v.completed.Format(isoDate), v.display())
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// siteMonth is a page of the exported site: a month's calendar followed by
+// its entries.
+type siteMonth struct {
+	Title   string
+	File    string
+	Weeks   [][]siteDay
+	Entries []*siteEntry
+	Prev    *siteMonth
+	Next    *siteMonth
+
+	year  int
+	month time.Month
+}
+
+// siteDay is a cell in a month's calendar. Day is 0 for padding.
+type siteDay struct {
+	Day    int
+	Anchor string
+	Entry  bool
+	Files  bool
+}
+
+type siteEntry struct {
+	*exportDay
+	Anchor string
+	Links  []siteLink
+}
+
+type siteLink struct {
+	Name string
+	Href string
+}
+
+type siteIndex struct {
+	Journal string
+	Months  []*siteMonth
+	Aliases []siteAlias
+	Todos   []string
+}
+
+type siteAlias struct {
+	Name string
+	Date string
+	Href string
+}
+
+// exportSite writes the journal as a static website to dir, with an index
+// page and a page per month mirroring the calendar view, plus every month on
+// one page for printing. Attached files are copied in under files/.
+func exportSite(path string, j *exportJournal, dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	var months []*siteMonth
+	byDate := make(map[string]string) // year/month/day -> link to the entry
+	for _, e := range j.Entries {
+		if n := len(months); n == 0 || months[n-1].year != e.t.Year() || months[n-1].month != e.t.Month() {
+			months = append(months, newSiteMonth(e.t))
+		}
+		month := months[len(months)-1]
+
+		se := &siteEntry{
+			exportDay: e,
+			Anchor:    fmt.Sprintf("d%d-%d-%d", e.t.Year(), int(e.t.Month()), e.t.Day()),
+		}
+		for _, f := range e.Files {
+			rel := filepath.Join("files", filepath.FromSlash(e.Date), f)
+			err = exportFile(path, filepath.Join(dayPath(path, e.t), f), filepath.Join(dir, rel))
+			if err != nil {
+				return err
+			}
+			se.Links = append(se.Links, siteLink{Name: f, Href: siteHref(rel)})
+		}
+		month.Entries = append(month.Entries, se)
+		month.mark(e.t.Day(), se.Anchor, e.Text != "", len(e.Files) > 0)
+		byDate[e.Date] = month.File + "#" + se.Anchor
+	}
+	for i, m := range months {
+		if i > 0 {
+			m.Prev = months[i-1]
+		}
+		if i < len(months)-1 {
+			m.Next = months[i+1]
+		}
+	}
+
+	for _, m := range months {
+		err = writeSitePage(filepath.Join(dir, m.File), "month", m)
+		if err != nil {
+			return err
+		}
+	}
+
+	index := &siteIndex{
+		Journal: j.Journal,
+		Months:  months,
+	}
+	for _, k := range j.sortedAliases() {
+		index.Aliases = append(index.Aliases, siteAlias{
+			Name: k,
+			Date: j.Aliases[k],
+			Href: byDate[j.Aliases[k]],
+		})
+	}
+	for _, v := range j.Todos {
+		index.Todos = append(index.Todos, v.display())
+	}
+
+	err = writeSitePage(filepath.Join(dir, "print.html"), "print", index)
+	if err != nil {
+		return err
+	}
+	return writeSitePage(filepath.Join(dir, "index.html"), "index", index)
+}
+
+func newSiteMonth(t time.Time) *siteMonth {
+	m := &siteMonth{
+		Title: fmt.Sprintf("%v %d", t.Month(), t.Year()),
+		File:  fmt.Sprintf("%d-%02d.html", t.Year(), int(t.Month())),
+		year:  t.Year(),
+		month: t.Month(),
+	}
+
+	// weeks start on sunday, as in the calendar command
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	week := make([]siteDay, int(first.Weekday()))
+	for day := 1; day <= daysIn(int(t.Month()), t.Year()); day++ {
+		week = append(week, siteDay{Day: day})
+		if len(week) == 7 {
+			m.Weeks = append(m.Weeks, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		m.Weeks = append(m.Weeks, append(week, make([]siteDay, 7-len(week))...))
+	}
+	return m
+}
+
+// mark links a day of the month to its anchor, noting whether it has an entry,
+// attached files or both, as the terminal calendar shows them.
+func (m *siteMonth) mark(day int, anchor string, entry, files bool) {
+	for _, w := range m.Weeks {
+		for i := range w {
+			if w[i].Day == day {
+				w[i].Anchor = anchor
+				w[i].Entry = entry
+				w[i].Files = files
+			}
+		}
+	}
+}
+
+// siteHref returns a relative link to a file in the site.
+func siteHref(rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func writeSitePage(name, tmpl string, data any) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = siteTemplates.ExecuteTemplate(f, tmpl, data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportFile copies an attached file out of the journal, decrypted.
+func exportFile(path, src, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	data, err := readJournalFile(path, src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+var siteTemplates = template.Must(template.New("site").Parse(siteHTML))
+
+const siteHTML = `{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>{{.}}</title>
+	<style>
+		body {
+			font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
+			color: #333;
+			max-width: 800px;
+			margin: 0 auto;
+			padding: 20px;
+		}
+
+		a {
+			color: #007bff;
+		}
+
+		nav {
+			display: flex;
+			justify-content: space-between;
+			margin-bottom: 20px;
+		}
+
+		table.calendar {
+			border-collapse: collapse;
+			margin-bottom: 30px;
+		}
+
+		table.calendar th, table.calendar td {
+			border: 1px solid #ddd;
+			width: 40px;
+			height: 32px;
+			text-align: center;
+		}
+
+		td.entry {
+			background-color: #e6f4ea;
+			font-weight: bold;
+		}
+
+		td.files {
+			background-color: #e8f0fe;
+		}
+
+		.entry-text {
+			white-space: pre-wrap;
+			font-family: inherit;
+		}
+
+		.day {
+			break-inside: avoid-page;
+			border-top: 1px solid #ddd;
+			padding-top: 10px;
+		}
+
+		@media print {
+			nav, table.calendar {
+				display: none;
+			}
+
+			.month {
+				break-before: page;
+			}
+		}
+	</style>
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" .Journal}}
+	<h1>{{.Journal}}</h1>
+	<h2>Entries</h2>
+	<p><a href="print.html">All entries on one page</a>, for printing or saving as PDF</p>
+	<ul>
+	{{range .Months}}	<li><a href="{{.File}}">{{.Title}}</a> ({{len .Entries}})</li>
+	{{end}}</ul>
+	{{if .Aliases}}<h2>Aliases</h2>
+	<ul>
+	{{range .Aliases}}	<li>{{.Name}}: {{if .Href}}<a href="{{.Href}}">{{.Date}}</a>{{else}}{{.Date}}{{end}}</li>
+	{{end}}</ul>
+	{{end}}{{if .Todos}}<h2>Todos</h2>
+	<ul>
+	{{range .Todos}}	<li>{{.}}</li>
+	{{end}}</ul>
+	{{end}}</body>
+</html>
+{{end}}
+
+{{define "month"}}{{template "head" .Title}}
+	<nav>
+		<span>{{with .Prev}}<a href="{{.File}}">&larr; {{.Title}}</a>{{end}}</span>
+		<a href="index.html">Index</a>
+		<span>{{with .Next}}<a href="{{.File}}">{{.Title}} &rarr;</a>{{end}}</span>
+	</nav>
+	{{template "monthbody" .}}
+</body>
+</html>
+{{end}}
+
+{{define "print"}}{{template "head" .Journal}}
+	{{range .Months}}{{template "monthbody" .}}
+	{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "monthbody"}}<div class="month">
+	<h1>{{.Title}}</h1>
+	<table class="calendar">
+		<tr><th>Su</th><th>Mo</th><th>Tu</th><th>We</th><th>Th</th><th>Fr</th><th>Sa</th></tr>
+		{{range .Weeks}}<tr>{{range .}}{{if .Anchor}}<td class="{{if .Entry}}entry{{else}}files{{end}}"><a href="#{{.Anchor}}">{{.Day}}</a>{{if .Files}}*{{end}}</td>{{else if .Day}}<td>{{.Day}}</td>{{else}}<td></td>{{end}}{{end}}</tr>
+		{{end}}
+	</table>
+	{{range .Entries}}<div class="day" id="{{.Anchor}}">
+		<h2>{{.Date}} {{.Weekday}}</h2>
+		<pre class="entry-text">{{.Text}}</pre>
+		{{if .Links}}<p>Files:{{range .Links}} <a href="{{.Href}}">{{.Name}}</a>{{end}}</p>{{end}}
+	</div>
+	{{end}}</div>
+{{end}}`
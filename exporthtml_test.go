@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSiteMonth(t *testing.T) {
+	// october 2026 starts on a thursday and has 31 days
+	m := newSiteMonth(time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local))
+
+	if m.File != "2026-10.html" {
+		t.Errorf("got file %v", m.File)
+	}
+	if len(m.Weeks) != 5 {
+		t.Fatalf("got %v weeks, want 5", len(m.Weeks))
+	}
+	for i, w := range m.Weeks {
+		if len(w) != 7 {
+			t.Errorf("week %v has %v days", i, len(w))
+		}
+	}
+	if m.Weeks[0][3].Day != 0 || m.Weeks[0][4].Day != 1 {
+		t.Errorf("first week: %v", m.Weeks[0])
+	}
+	if m.Weeks[4][6].Day != 31 {
+		t.Errorf("last week: %v", m.Weeks[4])
+	}
+}
+
+func TestSiteMonthMark(t *testing.T) {
+	m := newSiteMonth(time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local))
+	m.mark(1, "d2026-10-1", true, false)
+	m.mark(2, "d2026-10-2", false, true)
+
+	entry, files := m.Weeks[0][4], m.Weeks[0][5]
+	if !entry.Entry || entry.Files || entry.Anchor == "" {
+		t.Errorf("entry day: %+v", entry)
+	}
+	if files.Entry || !files.Files || files.Anchor == "" {
+		t.Errorf("files day: %+v", files)
+	}
+	if m.Weeks[0][6].Anchor != "" {
+		t.Errorf("unmarked day: %+v", m.Weeks[0][6])
+	}
+}
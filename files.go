@@ -2,11 +2,8 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -54,65 +51,8 @@ func files(path string, x []string) error {
 	}
 }
 
-func parseDateArg(x []string) (year, month, day int, rest []string, err error) {
-	if len(x) == 0 {
-		err = fmt.Errorf("date required (year/month/day, today, yesterday, tomorrow)")
-		return
-	}
-
-	dateArg := x[0]
-	rest = x[1:]
-
-	// check for a specific date first
-	if f := strings.Split(dateArg, "/"); len(f) == 3 {
-		year, err = strconv.Atoi(f[0])
-		if err != nil {
-			err = fmt.Errorf("invalid year: %v: %v", f[0], err)
-			return
-		}
-		month, err = strconv.Atoi(f[1])
-		if err != nil {
-			err = fmt.Errorf("invalid month: %v: %v", f[1], err)
-			return
-		}
-		day, err = strconv.Atoi(f[2])
-		if err != nil {
-			err = fmt.Errorf("invalid day: %v: %v", f[2], err)
-			return
-		}
-	} else {
-		dateCommands := []string{"today", "yesterday", "tomorrow"}
-		var r string
-		r, err = Apropos(dateArg, dateCommands)
-		if err != nil {
-			err = fmt.Errorf("%w\n%v", err, dateHelp)
-			return
-		}
-
-		when := time.Now()
-		switch r {
-		case "today":
-		case "yesterday":
-			when = when.Add(-24 * time.Hour)
-		case "tomorrow":
-			when = when.Add(24 * time.Hour)
-		}
-		year = when.Year()
-		month = int(when.Month())
-		day = when.Day()
-	}
-
-	// validate date
-	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	valid := t.Year() == year && t.Month() == time.Month(month) && t.Day() == day
-	if !valid {
-		err = fmt.Errorf("invalid date: %v/%v/%v", year, month, day)
-	}
-	return
-}
-
 func filesAdd(path string, x []string) error {
-	year, month, day, rest, err := parseDateArg(x)
+	t, rest, err := parseDateArg(path, x)
 	if err != nil {
 		return err
 	}
@@ -135,36 +75,48 @@ func filesAdd(path string, x []string) error {
 		return fmt.Errorf("cannot add directory: %v", srcPath)
 	}
 
-	err = syncPull(path)
+	data, err := os.ReadFile(srcPath)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		return err
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
-	err = os.MkdirAll(datePath, 0755)
+	return fileAdd(path, t, filepath.Base(srcPath), data)
+}
+
+// fileAdd attaches a file named name to day t, replacing any file of the
+// same name.
+func fileAdd(path string, t time.Time, name string, data []byte) error {
+	if !validFileName(name) {
+		return fmt.Errorf("invalid file name: %v", name)
+	}
+
+	unlock, err := lockJournal(path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	// copy file
-	srcFile, err := os.Open(srcPath)
+	err = syncPull(path)
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, err)
 	}
-	defer srcFile.Close()
 
-	dstPath := filepath.Join(datePath, filepath.Base(srcPath))
-	dstFile, err := os.Create(dstPath)
+	datePath := dayPath(path, t)
+	err = os.MkdirAll(datePath, 0755)
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
+	err = writeJournalFile(path, filepath.Join(datePath, name), data)
 	if err != nil {
 		return err
 	}
 
+	err = indexUpdateDay(path, datePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
 	err = syncPush(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -174,7 +126,7 @@ func filesAdd(path string, x []string) error {
 }
 
 func filesList(path string, x []string) error {
-	year, month, day, rest, err := parseDateArg(x)
+	t, rest, err := parseDateArg(path, x)
 	if err != nil {
 		return err
 	}
@@ -188,7 +140,7 @@ func filesList(path string, x []string) error {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+	datePath := dayPath(path, t)
 	files, err := listFilesInDay(datePath)
 	if err != nil {
 		return err
@@ -201,7 +153,7 @@ func filesList(path string, x []string) error {
 }
 
 func filesRemove(path string, x []string) error {
-	year, month, day, rest, err := parseDateArg(x)
+	t, rest, err := parseDateArg(path, x)
 	if err != nil {
 		return err
 	}
@@ -214,13 +166,22 @@ func filesRemove(path string, x []string) error {
 	}
 
 	filename := rest[0]
+	if !validFileName(filename) {
+		return fmt.Errorf("invalid file name: %v", filename)
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	err = syncPull(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+	datePath := dayPath(path, t)
 	filePath := filepath.Join(datePath, filename)
 
 	// check file exists
@@ -233,6 +194,11 @@ func filesRemove(path string, x []string) error {
 		return err
 	}
 
+	err = indexUpdateDay(path, datePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
 	err = syncPush(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -242,7 +208,7 @@ func filesRemove(path string, x []string) error {
 }
 
 func filesCopy(path string, x []string) error {
-	year, month, day, rest, err := parseDateArg(x)
+	t, rest, err := parseDateArg(path, x)
 	if err != nil {
 		return err
 	}
@@ -256,13 +222,16 @@ func filesCopy(path string, x []string) error {
 
 	filename := rest[0]
 	destPath := rest[1]
+	if !validFileName(filename) {
+		return fmt.Errorf("invalid file name: %v", filename)
+	}
 
 	err = syncPull(path)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+	datePath := dayPath(path, t)
 	srcPath := filepath.Join(datePath, filename)
 
 	// check source file exists
@@ -270,21 +239,13 @@ func filesCopy(path string, x []string) error {
 		return fmt.Errorf("file not found: %v", filename)
 	}
 
-	// copy file out
-	srcFile, err := os.Open(srcPath)
+	// copy file out, decrypted
+	data, err := readJournalFile(path, srcPath)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
 
-	dstFile, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	return os.WriteFile(destPath, data, 0644)
 }
 
 // listFilesInDay returns all files in a day's directory except "entry"
@@ -306,6 +267,12 @@ func listFilesInDay(datePath string) ([]string, error) {
 	return files, nil
 }
 
+// validFileName reports whether name may be a file attached to a day: a
+// plain name, not a path, that isn't the entry.
+func validFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name && name != entryName
+}
+
 // hasFilesInDay returns true if a day has any files other than "entry"
 func hasFilesInDay(datePath string) bool {
 	files, err := listFilesInDay(datePath)
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidFileName(t *testing.T) {
+	tests := map[string]bool{
+		"photo.jpg":    true,
+		".notes":       true,
+		"":             false,
+		".":            false,
+		"..":           false,
+		"entry":        false,
+		"../key":       false,
+		"a/b.txt":      false,
+		"/etc/passwd":  false,
+		"notes.tar.gz": true,
+	}
+
+	for in, want := range tests {
+		if got := validFileName(in); got != want {
+			t.Errorf("%q: got %v, want %v", in, got, want)
+		}
+	}
+}
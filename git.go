@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"strconv"
+	"path/filepath"
+	"slices"
+	"strings"
+	gosync "sync" // sync is the sync command
 	"time"
 )
 
@@ -16,16 +21,44 @@ func useGit(path string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	return c.git, nil
+}
+
+// backgroundSyncs holds the journals that serve syncs in the background,
+// each with a channel asking for a sync. Commands don't pull these before
+// reading or changing them, and leave pushing to the background sync, so that
+// a request doesn't wait on the remote.
+var (
+	backgroundSyncs   = make(map[string]chan struct{})
+	backgroundSyncsMu gosync.Mutex
+)
+
+// setBackgroundSync records the channel asking for a sync of the journal at
+// path, or that it isn't synced in the background if nil.
+func setBackgroundSync(path string, c chan struct{}) {
+	backgroundSyncsMu.Lock()
+	defer backgroundSyncsMu.Unlock()
 
-	if c[configGit] == "" {
-		// not set at all
-		return false, nil
+	if c != nil {
+		backgroundSyncs[path] = c
+	} else {
+		delete(backgroundSyncs, path)
 	}
+}
+
+func backgroundSync(path string) (chan struct{}, bool) {
+	backgroundSyncsMu.Lock()
+	defer backgroundSyncsMu.Unlock()
 
-	return strconv.ParseBool(c[configGit])
+	c, ok := backgroundSyncs[path]
+	return c, ok
 }
 
 func syncPull(path string) error {
+	if _, ok := backgroundSync(path); ok {
+		return nil
+	}
+
 	g, err := useGit(path)
 	if err != nil {
 		return err
@@ -34,7 +67,58 @@ func syncPull(path string) error {
 		return nil
 	}
 
-	return doGitPull(path)
+	head := gitHead(path)
+	err = doGitPull(path)
+	if err != nil {
+		return err
+	}
+	if gitHead(path) != head {
+		return indexPulled(path, head)
+	}
+	return nil
+}
+
+// gitHead returns the current commit, or an empty string if there is none.
+func gitHead(path string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Env = os.Environ()
+	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// gitChangedDays returns the days (year/month/day) with files changed
+// between commit from and the current one.
+func gitChangedDays(path, from string) ([]string, error) {
+	if from == "" {
+		return nil, fmt.Errorf("no commit to compare")
+	}
+
+	cmd := exec.Command("git", "diff", "--name-only", "-z", from, "HEAD")
+	cmd.Env = os.Environ()
+	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sync diff: %w", err)
+	}
+
+	var dates []string
+	for _, name := range strings.Split(string(output), "\x00") {
+		parts := strings.Split(name, "/")
+		if len(parts) != 4 {
+			continue
+		}
+		if _, err := parseYMD(parts[:3]); err != nil {
+			continue
+		}
+		if d := strings.Join(parts[:3], "/"); !slices.Contains(dates, d) {
+			dates = append(dates, d)
+		}
+	}
+	return dates, nil
 }
 
 func doGitPull(path string) error {
@@ -49,6 +133,15 @@ func doGitPull(path string) error {
 }
 
 func syncPush(path string) error {
+	if c, ok := backgroundSync(path); ok {
+		// a sync already asked for will push this too
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
 	g, err := useGit(path)
 	if err != nil {
 		return err
@@ -61,6 +154,12 @@ func syncPush(path string) error {
 }
 
 func doGitPush(path string) error {
+	// temporary files left by an interrupted write aren't journal files
+	err := gitignoreAdd(path, atomicTempIgnore)
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command("git", "add", "-A")
 	cmd.Env = os.Environ()
 	cmd.Dir = path
@@ -69,12 +168,24 @@ func doGitPush(path string) error {
 		return fmt.Errorf("sync add: %w: %v", err, string(output))
 	}
 
-	cmd = exec.Command("git", "commit", "-m", fmt.Sprintf("tagebuch %v", time.Now()))
+	// commit only if there are changes, but push regardless, in case an
+	// earlier push failed
+	cmd = exec.Command("git", "status", "--porcelain")
 	cmd.Env = os.Environ()
 	cmd.Dir = path
-	output, err = cmd.CombinedOutput()
+	output, err = cmd.Output()
 	if err != nil {
-		return fmt.Errorf("sync commit: %w: %v", err, string(output))
+		return fmt.Errorf("sync status: %w", err)
+	}
+
+	if len(bytes.TrimSpace(output)) > 0 {
+		cmd = exec.Command("git", "commit", "-m", fmt.Sprintf("tagebuch %v", time.Now()))
+		cmd.Env = os.Environ()
+		cmd.Dir = path
+		output, err = cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("sync commit: %w: %v", err, string(output))
+		}
 	}
 
 	cmd = exec.Command("git", "push")
@@ -88,6 +199,32 @@ func doGitPush(path string) error {
 	return nil
 }
 
+// gitignoreAdd adds line to the journal's .gitignore if it isn't there.
+func gitignoreAdd(path, line string) error {
+	pg := filepath.Join(path, ".gitignore")
+	data, err := os.ReadFile(pg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, v := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(v) == line {
+			return nil
+		}
+	}
+
+	f, err := os.OpenFile(pg, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		line = "\n" + line
+	}
+	_, err = f.WriteString(line + "\n")
+	return err
+}
+
 // sync performs a manual git sync (pull then push), regardless of config
 func sync(path string, x []string) error {
 	if len(x) > 0 {
@@ -97,9 +234,13 @@ func sync(path string, x []string) error {
 		return err
 	}
 
+	head := gitHead(path)
 	if err := doGitPull(path); err != nil {
 		log.Println(err)
 	}
+	if err := indexPulled(path, head); err != nil {
+		log.Println(err)
+	}
 	err := doGitPush(path)
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignoreAdd(t *testing.T) {
+	path := t.TempDir()
+	pg := filepath.Join(path, ".gitignore")
+	if err := os.WriteFile(pg, []byte("/index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := gitignoreAdd(path, atomicTempIgnore); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "/index\n"+atomicTempIgnore+"\n" {
+		t.Fatalf("invalid .gitignore: %q", data)
+	}
+}
+
+func TestAtomicTempIgnore(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "."+entryName+".tmp*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ok, err := filepath.Match(atomicTempIgnore, filepath.Base(f.Name()))
+	if err != nil || !ok {
+		t.Fatal("temporary file not ignored", f.Name())
+	}
+	if ok, _ := filepath.Match(atomicTempIgnore, ".entry.tmpl"); ok {
+		t.Fatal("ignored a template")
+	}
+}
+
+func TestSyncInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+
+	c := make(chan struct{}, 1)
+	setBackgroundSync(path, c)
+	if err := syncPull(path); err != nil {
+		t.Fatal("pulled a journal synced in the background:", err)
+	}
+	for range 2 {
+		if err := syncPush(path); err != nil {
+			t.Fatal("pushed a journal synced in the background:", err)
+		}
+	}
+	if len(c) != 1 {
+		t.Fatal("push not queued")
+	}
+
+	setBackgroundSync(path, nil)
+	if err := syncPull(path); err == nil {
+		t.Fatal("expected error for a missing journal")
+	}
+}
@@ -1,3 +1,11 @@
 module github.com/djfritz/tb
 
 go 1.25.2
+
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	golang.org/x/crypto v0.54.0
+	golang.org/x/term v0.45.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var importCommands = &Options{
+	commands: []string{
+		"jrnl",
+		"dayone",
+		"markdown",
+	},
+	descriptions: []string{
+		"a jrnl journal file or jrnl --export json output",
+		"a Day One JSON export, the JSON file or its unzipped directory",
+		"a directory of markdown or text files with dates in their names",
+	},
+}
+
+// importJournal adds entries from another journal. Entries are merged into
+// any existing entry for the day, and a day that already contains what is
+// being imported is left alone, so importing again is harmless.
+func importJournal(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	if len(x) == 0 {
+		return fmt.Errorf("usage: import <format> <path>. Formats are:\n%v", importCommands)
+	}
+
+	r, err := Apropos(x[0], importCommands.commands)
+	if err != nil {
+		return err
+	}
+
+	if len(x) < 2 {
+		return fmt.Errorf("usage: import %v <path>", r)
+	}
+	if len(x) > 2 {
+		return fmt.Errorf("trailing commands: %v", x[2:])
+	}
+	src := x[1]
+
+	var entries []*importEntry
+	switch r {
+	case "jrnl":
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		entries, err = parseJrnl(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case "dayone":
+		entries, err = parseDayOne(src)
+		if err != nil {
+			return err
+		}
+	case "markdown":
+		var skipped []string
+		entries, skipped, err = parseMarkdownDir(src)
+		if err != nil {
+			return err
+		}
+		for _, v := range skipped {
+			fmt.Fprintf(os.Stderr, "skipped %v: no date in its name\n", v)
+		}
+	default:
+		return fmt.Errorf("invalid command %v", r)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("nothing to import")
+		return nil
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	days := make(map[string][]*importEntry)
+	for _, e := range entries {
+		d := dateString(e.t)
+		days[d] = append(days[d], e)
+	}
+	var order []string
+	for d := range days {
+		order = append(order, d)
+	}
+	sort.Slice(order, func(i, j int) bool { return compareDates(order[i], order[j]) })
+
+	var added, merged, unchanged, files int
+	for _, d := range order {
+		e := days[d]
+		sort.SliceStable(e, func(i, j int) bool { return e[i].t.Before(e[j].t) })
+
+		result, n, err := importDay(path, dayPath(path, e[0].t), e)
+		if err != nil {
+			return fmt.Errorf("%v: %w", d, err)
+		}
+		files += n
+
+		switch result {
+		case "new":
+			added++
+		case "merged":
+			merged++
+		default:
+			unchanged++
+		}
+		if n > 0 {
+			result += fmt.Sprintf(", %v files", n)
+		}
+		fmt.Printf("%v: %v\n", d, result)
+	}
+
+	fmt.Printf("imported %v entries into %v days: %v new, %v merged, %v unchanged, %v files\n", len(entries), len(order), added, merged, unchanged, files)
+
+	err = indexUpdateDays(path, order)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return nil
+}
+
+// importDay writes entries to a day, appending them to any existing entry,
+// and copies in their attachments. It returns whether the entry is new,
+// merged or unchanged, and the number of files copied.
+func importDay(path, datePath string, entries []*importEntry) (string, int, error) {
+	err := os.MkdirAll(datePath, 0755)
+	if err != nil {
+		return "", 0, err
+	}
+
+	copied := 0
+	var texts []string
+	for _, e := range entries {
+		text := e.render()
+		for _, src := range e.files {
+			name, ok, err := importFile(path, datePath, src)
+			if err != nil {
+				return "", 0, err
+			}
+			if ok {
+				copied++
+			}
+			if old := filepath.Base(src); name != old {
+				text = strings.ReplaceAll(text, "("+old+")", "("+name+")")
+			}
+		}
+		texts = append(texts, text)
+	}
+	text := strings.Join(texts, "\n\n")
+
+	filename := filepath.Join(datePath, entryName)
+	existing, err := readJournalFile(path, filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", 0, err
+	}
+
+	old := strings.TrimRight(string(existing), "\n")
+	result := "merged"
+	switch {
+	case strings.Contains(old, text):
+		return "unchanged", copied, nil
+	case old == "":
+		result = "new"
+	default:
+		text = old + "\n\n" + text
+	}
+
+	err = writeJournalFile(path, filename, []byte(text+"\n"))
+	if err != nil {
+		return "", 0, err
+	}
+	return result, copied, nil
+}
+
+// importFile copies src into a day unless it is already there. A different
+// file with the same name is kept, and src is given a numbered name instead.
+// It returns the name src has in the day and whether it was copied.
+func importFile(path, datePath, src string) (string, bool, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", false, err
+	}
+
+	base := filepath.Base(src)
+	ext := filepath.Ext(base)
+	name := base
+	for i := 1; ; i++ {
+		existing, err := readJournalFile(path, filepath.Join(datePath, name))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return "", false, err
+		}
+		if bytes.Equal(existing, data) {
+			return name, false, nil
+		}
+		name = fmt.Sprintf("%v-%d%v", strings.TrimSuffix(base, ext), i, ext)
+	}
+
+	err = writeJournalFile(path, filepath.Join(datePath, name), data)
+	if err != nil {
+		return "", false, err
+	}
+	return name, true, nil
+}
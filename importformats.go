@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// importEntry is an entry read from another journal.
+type importEntry struct {
+	t     time.Time
+	timed bool // t has a time of day worth keeping
+	text  string
+	files []string // attachments to copy into the day
+}
+
+var reJrnlHeader = regexp.MustCompile(`(?i)^\[?(\d{4}-\d{2}-\d{2}) (\d{1,2}:\d{2}(?::\d{2})?(?: ?[ap]m)?)\]? ?(.*)$`)
+
+var jrnlTimes = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04:05 PM"}
+
+// parseJrnl reads a jrnl journal file, where each entry starts with a line
+// such as "[2026-01-06 09:30] Title", or the output of jrnl --export json.
+func parseJrnl(r io.Reader) ([]*importEntry, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(1); err == nil && b[0] == '{' {
+		return parseJrnlJSON(br)
+	}
+
+	var ret []*importEntry
+	var body []string
+	flush := func() {
+		if len(ret) > 0 {
+			e := ret[len(ret)-1]
+			e.text = strings.TrimSpace(e.text + "\n" + strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := reJrnlHeader.FindStringSubmatch(line)
+		if m == nil {
+			if len(ret) == 0 && strings.TrimSpace(line) != "" {
+				return nil, fmt.Errorf("not a jrnl file, expected an entry: %v", line)
+			}
+			body = append(body, line)
+			continue
+		}
+
+		flush()
+		t, err := parseJrnlTime(m[1], m[2])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &importEntry{t: t, timed: true, text: m[3]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return ret, nil
+}
+
+func parseJrnlTime(date, clock string) (time.Time, error) {
+	for _, layout := range jrnlTimes {
+		t, err := time.ParseInLocation(isoDate+" "+layout, date+" "+strings.ToUpper(clock), time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid jrnl date: %v %v", date, clock)
+}
+
+func parseJrnlJSON(r io.Reader) ([]*importEntry, error) {
+	var j struct {
+		Entries []struct {
+			Date  string `json:"date"`
+			Time  string `json:"time"`
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"entries"`
+	}
+	err := json.NewDecoder(r).Decode(&j)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*importEntry
+	for _, v := range j.Entries {
+		t, err := parseJrnlTime(v.Date, v.Time)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, &importEntry{
+			t:     t,
+			timed: true,
+			text:  strings.TrimSpace(v.Title + "\n" + v.Body),
+		})
+	}
+	return ret, nil
+}
+
+// parseDayOne reads a Day One JSON export, either the JSON file or the
+// unzipped export directory. Photos are read from the photos directory next
+// to the JSON file, and links to them in the text are changed to the name
+// they are attached with.
+func parseDayOne(src string) ([]*importEntry, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{src}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(src, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no Day One JSON files in %v", src)
+		}
+	}
+
+	var ret []*importEntry
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := parseDayOneJSON(f, filepath.Join(filepath.Dir(name), "photos"))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", name, err)
+		}
+		ret = append(ret, entries...)
+	}
+	return ret, nil
+}
+
+func parseDayOneJSON(r io.Reader, photos string) ([]*importEntry, error) {
+	var j struct {
+		Entries []struct {
+			CreationDate time.Time `json:"creationDate"`
+			TimeZone     string    `json:"timeZone"`
+			Text         string    `json:"text"`
+			Photos       []struct {
+				Identifier string `json:"identifier"`
+				MD5        string `json:"md5"`
+				Type       string `json:"type"`
+			} `json:"photos"`
+		} `json:"entries"`
+	}
+	err := json.NewDecoder(r).Decode(&j)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*importEntry
+	for _, v := range j.Entries {
+		loc := time.Local
+		if v.TimeZone != "" {
+			if l, err := time.LoadLocation(v.TimeZone); err == nil {
+				loc = l
+			}
+		}
+
+		e := &importEntry{
+			t:     v.CreationDate.In(loc),
+			timed: true,
+			text:  strings.TrimSpace(v.Text),
+		}
+		for _, p := range v.Photos {
+			name := p.MD5 + "." + p.Type
+			if _, err := os.Stat(filepath.Join(photos, name)); err != nil {
+				// the type isn't always the extension, e.g. jpeg and jpg
+				m, _ := filepath.Glob(filepath.Join(photos, p.MD5+".*"))
+				if len(m) == 0 {
+					continue
+				}
+				name = filepath.Base(m[0])
+			}
+			e.files = append(e.files, filepath.Join(photos, name))
+			e.text = strings.ReplaceAll(e.text, "dayone-moment://"+p.Identifier, name)
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+var (
+	reDatedName = regexp.MustCompile(`(\d{4})[-/_.](\d{1,2})[-/_.](\d{1,2})`)
+	reMDLink    = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// parseMarkdownDir reads markdown and text files anywhere under dir that have
+// a date in their path, such as 2026-01-06.md or 2026/01/06.md. Files linked
+// from them by relative path are attached. It also returns the files that
+// were skipped for having no date.
+func parseMarkdownDir(dir string) ([]*importEntry, []string, error) {
+	var ret []*importEntry
+	var skipped []string
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".md", ".markdown", ".txt":
+		default:
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		m := reDatedName.FindAllStringSubmatch(filepath.ToSlash(rel), -1)
+		if m == nil {
+			skipped = append(skipped, rel)
+			return nil
+		}
+		t, err := parseYMD(m[len(m)-1][1:])
+		if err != nil {
+			skipped = append(skipped, rel)
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		e := &importEntry{t: t, text: strings.TrimSpace(string(data))}
+
+		// attach local files the entry links to, by their base name
+		e.text = reMDLink.ReplaceAllStringFunc(e.text, func(s string) string {
+			link := reMDLink.FindStringSubmatch(s)[1]
+			if strings.Contains(link, "://") || strings.HasPrefix(link, "#") || filepath.IsAbs(link) {
+				return s
+			}
+			src := filepath.Join(filepath.Dir(p), filepath.FromSlash(link))
+			info, err := os.Stat(src)
+			if err != nil || !info.Mode().IsRegular() {
+				return s
+			}
+			e.files = append(e.files, src)
+			return "](" + filepath.Base(src) + ")"
+		})
+
+		ret = append(ret, e)
+		return nil
+	})
+	return ret, skipped, err
+}
+
+// render returns the entry's text as written to a day, with its time if it
+// has one.
+func (e *importEntry) render() string {
+	if !e.timed {
+		return e.text
+	}
+	return e.t.Format(noteTime) + " " + e.text
+}
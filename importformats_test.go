@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJrnl(t *testing.T) {
+	text := `[2025-03-01 09:30] Morning.
+Coffee.
+
+[2025-03-01 02:15 PM] Afternoon.
+`
+	e, err := parseJrnl(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e) != 2 {
+		t.Fatalf("got %v entries, want 2", len(e))
+	}
+
+	want := time.Date(2025, 3, 1, 9, 30, 0, 0, time.Local)
+	if !e[0].t.Equal(want) || e[0].text != "Morning.\nCoffee." {
+		t.Errorf("got %v %q", e[0].t, e[0].text)
+	}
+	if e[1].t.Hour() != 14 || e[1].render() != "14:15 Afternoon." {
+		t.Errorf("got %v %q", e[1].t, e[1].render())
+	}
+
+	_, err = parseJrnl(strings.NewReader("not a journal\n"))
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseJrnlJSON(t *testing.T) {
+	text := `{"entries": [{"date": "2025-03-02", "time": "20:00", "title": "Late.", "body": "Body\n"}]}`
+
+	e, err := parseJrnl(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e) != 1 || dateString(e[0].t) != "2025/3/2" || e[0].text != "Late.\nBody" {
+		t.Errorf("got %+v", e)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var indexCommands = &Options{
+	commands: []string{
+		"rebuild",
+	},
+	descriptions: []string{
+		"reindex every day from scratch, such as if the index is damaged",
+	},
+}
+
+const (
+	tagebuchIndex = "index"
+	indexVersion  = 1
+
+	// configIndexGit commits the index along with entries when true,
+	// otherwise it is added to the journal's .gitignore.
+	configIndexGit = "index_git"
+)
+
+// searchIndex is an inverted index over entries, stored as JSON in the
+// journal. It is only maintained once it exists, i.e. after the first
+// indexed search, and then by reindexing the days that commands change. A
+// query only stats the journal to find days changed otherwise, rather than
+// reading every entry.
+type searchIndex struct {
+	Version int                       `json:"version"`
+	Days    map[string]indexDay       `json:"days"`  // date -> signature
+	Terms   map[string]map[string]int `json:"terms"` // term -> date -> count
+}
+
+// indexDay is enough information about a day on disk to tell whether it has
+// changed since it was indexed.
+type indexDay struct {
+	ModTime int64    `json:"mtime"`
+	Size    int64    `json:"size"`
+	Files   []string `json:"files,omitempty"`
+}
+
+func (d indexDay) equal(o indexDay) bool {
+	return d.ModTime == o.ModTime && d.Size == o.Size && slices.Equal(d.Files, o.Files)
+}
+
+// indexResult is a single day matching an indexed query.
+type indexResult struct {
+	date  string
+	score float64
+}
+
+// tokenize splits text into lowercase words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// scanDays walks the journal and returns the signature of every day
+// directory containing an entry or attached files.
+func scanDays(path string) map[string]indexDay {
+	days := make(map[string]indexDay)
+
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return nil
+		}
+		// rel is like "2026/1/6/entry"
+		parts := splitSlash(filepath.ToSlash(rel))
+		if len(parts) != 4 {
+			return nil
+		}
+		for _, v := range parts[:3] {
+			if _, err := strconv.Atoi(v); err != nil {
+				return nil
+			}
+		}
+		date := strings.Join(parts[:3], "/")
+
+		day := days[date]
+		if d.Name() == entryName {
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			day.ModTime = info.ModTime().UnixNano()
+			day.Size = info.Size()
+		} else {
+			// WalkDir visits in lexical order, so Files stays sorted
+			day.Files = append(day.Files, d.Name())
+		}
+		days[date] = day
+		return nil
+	})
+
+	return days
+}
+
+// indexExists reports whether the journal has a search index to maintain.
+func indexExists(path string) bool {
+	_, err := os.Stat(filepath.Join(path, tagebuchIndex))
+	return err == nil
+}
+
+func indexLoad(path string) (*searchIndex, error) {
+	data, err := readJournalFile(path, filepath.Join(path, tagebuchIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &searchIndex{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("invalid index: %w", err)
+	}
+	if idx.Version != indexVersion || idx.Days == nil || idx.Terms == nil {
+		return nil, fmt.Errorf("invalid index: unsupported version %v", idx.Version)
+	}
+	return idx, nil
+}
+
+func (idx *searchIndex) save(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	err = writeJournalFile(path, filepath.Join(path, tagebuchIndex), data)
+	if err != nil {
+		return err
+	}
+
+	return indexGitignore(path)
+}
+
+// indexGitignore keeps the index out of git unless configured otherwise.
+func indexGitignore(path string) error {
+	c, err := getConfig(path)
+	if err != nil {
+		return err
+	}
+	if c.indexGit {
+		return nil
+	}
+
+	return gitignoreAdd(path, "/"+tagebuchIndex)
+}
+
+// removeDay drops all postings for a day.
+func (idx *searchIndex) removeDay(date string) {
+	delete(idx.Days, date)
+	for term, postings := range idx.Terms {
+		delete(postings, date)
+		if len(postings) == 0 {
+			delete(idx.Terms, term)
+		}
+	}
+}
+
+// addDay indexes a day's entry text and attached file names.
+func (idx *searchIndex) addDay(path, date string, day indexDay) error {
+	text, err := readJournalFile(path, filepath.Join(path, date, entryName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	words := tokenize(string(text))
+	for _, v := range day.Files {
+		words = append(words, tokenize(v)...)
+	}
+
+	for _, w := range words {
+		if idx.Terms[w] == nil {
+			idx.Terms[w] = make(map[string]int)
+		}
+		idx.Terms[w][date]++
+	}
+	idx.Days[date] = day
+	return nil
+}
+
+// indexBuild indexes every day in the journal from scratch.
+func indexBuild(path string) (*searchIndex, error) {
+	idx := &searchIndex{
+		Version: indexVersion,
+		Days:    make(map[string]indexDay),
+		Terms:   make(map[string]map[string]int),
+	}
+
+	for date, day := range scanDays(path) {
+		if err := idx.addDay(path, date, day); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// refresh reindexes any days that have changed on disk, returning true if
+// anything was updated.
+func (idx *searchIndex) refresh(path string) (bool, error) {
+	days := scanDays(path)
+	changed := false
+
+	for date := range idx.Days {
+		if _, ok := days[date]; !ok {
+			idx.removeDay(date)
+			changed = true
+		}
+	}
+	for date, day := range days {
+		if old, ok := idx.Days[date]; ok && old.equal(day) {
+			continue
+		}
+		idx.removeDay(date)
+		if err := idx.addDay(path, date, day); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// indexOpen loads the index, building it from scratch if it is missing or
+// invalid. Days changed other than by tb, such as edited by hand, are found
+// by comparing each day's signature on disk, which only stats files, and
+// just those days are reindexed.
+func indexOpen(path string) (*searchIndex, error) {
+	idx, err := indexLoad(path)
+	if err != nil {
+		return indexRebuild(path)
+	}
+
+	changed, err := idx.refresh(path)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		err = idx.save(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// indexRebuild indexes the journal from scratch and saves the index.
+func indexRebuild(path string) (*searchIndex, error) {
+	idx, err := indexBuild(path)
+	if err != nil {
+		return nil, err
+	}
+	return idx, idx.save(path)
+}
+
+// indexUpdate brings an existing index up to date with the whole journal,
+// walking all of it. It does nothing if the journal has no index.
+func indexUpdate(path string) error {
+	if !indexExists(path) {
+		return nil
+	}
+
+	idx, err := indexLoad(path)
+	if err != nil {
+		_, err = indexRebuild(path)
+		return err
+	}
+
+	changed, err := idx.refresh(path)
+	if err != nil || !changed {
+		return err
+	}
+	return idx.save(path)
+}
+
+// indexUpdateDay reindexes a single day of an existing index, such as after
+// editing it. It does nothing if the journal has no index.
+func indexUpdateDay(path, datePath string) error {
+	rel, err := filepath.Rel(path, datePath)
+	if err != nil {
+		return err
+	}
+	return indexUpdateDays(path, []string{filepath.ToSlash(rel)})
+}
+
+// indexUpdateDays reindexes the given days (year/month/day) of an existing
+// index. It does nothing if the journal has no index.
+func indexUpdateDays(path string, dates []string) error {
+	if !indexExists(path) || len(dates) == 0 {
+		return nil
+	}
+
+	idx, err := indexLoad(path)
+	if err != nil {
+		return indexUpdate(path)
+	}
+
+	for _, date := range dates {
+		day, found, err := scanDay(filepath.Join(path, date))
+		if err != nil {
+			return err
+		}
+
+		idx.removeDay(date)
+		if found {
+			if err := idx.addDay(path, date, day); err != nil {
+				return err
+			}
+		}
+	}
+	return idx.save(path)
+}
+
+// indexPulled reindexes the days changed by git since commit from, such as
+// by a pull, or the whole journal if they can't be told.
+func indexPulled(path, from string) error {
+	if !indexExists(path) {
+		return nil
+	}
+
+	dates, err := gitChangedDays(path, from)
+	if err != nil {
+		return indexUpdate(path)
+	}
+	return indexUpdateDays(path, dates)
+}
+
+func index(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	if len(x) == 0 {
+		return fmt.Errorf("command required. Options are:\n%v", indexCommands)
+	}
+
+	r, err := Apropos(x[0], indexCommands.commands)
+	if err != nil {
+		return err
+	}
+	if len(x) > 1 {
+		return fmt.Errorf("trailing commands: %v", x[1:])
+	}
+
+	switch r {
+	case "rebuild":
+		idx, err := indexRebuild(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("indexed %v days\n", len(idx.Days))
+		return nil
+	default:
+		return fmt.Errorf("invalid command %v", r)
+	}
+}
+
+// scanDay returns the signature of a single day directory, and whether it
+// holds anything at all.
+func scanDay(datePath string) (indexDay, bool, error) {
+	var day indexDay
+
+	entries, err := os.ReadDir(datePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return day, false, nil
+		}
+		return day, false, err
+	}
+
+	found := false
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		found = true
+		if e.Name() == entryName {
+			info, err := e.Info()
+			if err != nil {
+				return day, false, err
+			}
+			day.ModTime = info.ModTime().UnixNano()
+			day.Size = info.Size()
+		} else {
+			day.Files = append(day.Files, e.Name())
+		}
+	}
+	return day, found, nil
+}
+
+// indexSearch answers a word and phrase query from the index, ranked by
+// relevance. Words must all appear in a day; "quoted phrases" must appear
+// in order. A missing or invalid index is rebuilt, and stale days reindexed.
+func indexSearch(path, query string, o *searchOptions) ([]indexResult, error) {
+	idx, err := indexOpen(path)
+	if err != nil {
+		return nil, err
+	}
+
+	words, phrases := parseQuery(query)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	// candidates must contain every word
+	var candidates []string
+	for date := range idx.Terms[words[0]] {
+		ok := o.inRange(date)
+		for _, w := range words[1:] {
+			if !ok {
+				break
+			}
+			_, ok = idx.Terms[w][date]
+		}
+		if ok {
+			candidates = append(candidates, date)
+		}
+	}
+
+	var results []indexResult
+	for _, date := range candidates {
+		if len(phrases) > 0 {
+			text, err := readJournalFile(path, filepath.Join(path, date, entryName))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			tokens := tokenize(string(text))
+			match := true
+			for _, p := range phrases {
+				if !containsPhrase(tokens, p) {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+		}
+
+		// tf-idf
+		var score float64
+		for _, w := range words {
+			idf := math.Log(1 + float64(len(idx.Days))/float64(len(idx.Terms[w])))
+			score += float64(idx.Terms[w][date]) * idf
+		}
+		results = append(results, indexResult{date: date, score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return compareDates(results[j].date, results[i].date)
+	})
+
+	return results, nil
+}
+
+// parseQuery splits a query into all of its words and any quoted phrases.
+func parseQuery(q string) ([]string, [][]string) {
+	var words []string
+	var phrases [][]string
+	for i, v := range strings.Split(q, `"`) {
+		tokens := tokenize(v)
+		if len(tokens) == 0 {
+			continue
+		}
+		if i%2 == 1 && len(tokens) > 1 {
+			phrases = append(phrases, tokens)
+		}
+		for _, t := range tokens {
+			if !slices.Contains(words, t) {
+				words = append(words, t)
+			}
+		}
+	}
+	return words, phrases
+}
+
+// containsPhrase reports whether phrase appears as a contiguous run of tokens.
+func containsPhrase(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
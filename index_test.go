@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	words, phrases := parseQuery(`Deploy "the release" notes`)
+
+	if !slices.Equal(words, []string{"deploy", "the", "release", "notes"}) {
+		t.Fatal("invalid words", words)
+	}
+	if len(phrases) != 1 || !slices.Equal(phrases[0], []string{"the", "release"}) {
+		t.Fatal("invalid phrases", phrases)
+	}
+}
+
+func TestContainsPhrase(t *testing.T) {
+	tokens := tokenize("We deployed the release, then wrote release notes.")
+
+	if !containsPhrase(tokens, []string{"the", "release"}) {
+		t.Fatal("missing phrase")
+	}
+	if containsPhrase(tokens, []string{"release", "the"}) {
+		t.Fatal("unexpected phrase")
+	}
+}
+
+func TestIndexUpdateDays(t *testing.T) {
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, tagebuchMagic), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	write := func(date, text string) {
+		day := filepath.Join(path, date)
+		if err := os.MkdirAll(day, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(day, entryName), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("2026/1/6", "deploy the release\n")
+	write("2026/1/7", "write notes\n")
+	if _, err := indexRebuild(path); err != nil {
+		t.Fatal(err)
+	}
+
+	write("2026/1/6", "plan the launch\n")
+	write("2026/1/8", "deploy again\n")
+	if err := indexUpdateDays(path, []string{"2026/1/6"}); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := indexLoad(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.Terms["launch"]["2026/1/6"]; !ok {
+		t.Fatal("day not reindexed")
+	}
+	if _, ok := idx.Terms["release"]; ok {
+		t.Fatal("stale term", idx.Terms["release"])
+	}
+	// only the days given are looked at
+	if _, ok := idx.Days["2026/1/8"]; ok {
+		t.Fatal("unexpected day")
+	}
+
+	// but a query notices days changed behind the index's back
+	results, err := indexSearch(path, "deploy", &searchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].date != "2026/1/8" {
+		t.Fatal("invalid results", results)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -15,8 +17,16 @@ func list(path string, x []string) error {
 		return err
 	}
 
-	if len(x) != 0 {
-		return fmt.Errorf("trailing commands: %v", x)
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fTag := fs.String("t", "", "only entries with a #tag or @mention")
+
+	err = fs.Parse(x)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("trailing commands: %v", fs.Args())
 	}
 
 	err = syncPull(path)
@@ -24,6 +34,24 @@ func list(path string, x []string) error {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
+	dates := entryDates(path)
+	if *fTag != "" {
+		dates, err = taggedDates(path, *fTag)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range dates {
+		fmt.Println(e)
+	}
+
+	return nil
+}
+
+// entryDates returns the year/month/day of every day with a non-empty entry,
+// sorted chronologically.
+func entryDates(path string) []string {
 	var entries []string
 
 	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
@@ -59,11 +87,24 @@ func list(path string, x []string) error {
 		return compareDates(entries[i], entries[j])
 	})
 
-	for _, e := range entries {
-		fmt.Println(e)
+	return entries
+}
+
+// dayDates returns the year/month/day of every day with a non-empty entry or
+// attached files, sorted chronologically.
+func dayDates(path string) []string {
+	var dates []string
+	for date, day := range scanDays(path) {
+		if day.Size > 0 || len(day.Files) > 0 {
+			dates = append(dates, date)
+		}
 	}
 
-	return nil
+	sort.Slice(dates, func(i, j int) bool {
+		return compareDates(dates[i], dates[j])
+	})
+
+	return dates
 }
 
 func compareDates(a, b string) bool {
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDayDates(t *testing.T) {
+	path := t.TempDir()
+	for name, data := range map[string]string{
+		"2026/1/10/entry":     "text",
+		"2026/1/6/photo.jpg":  "jpg",
+		"2025/12/31/entry":    "",
+		"2025/12/31/note.txt": "note",
+		"2025/12/30/entry":    "",
+		"templates/day":       "template",
+	} {
+		p := filepath.Join(path, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := dayDates(path)
+	want := []string{"2025/12/31", "2026/1/6", "2026/1/10"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// lockJournal takes an exclusive advisory lock on the journal, blocking
+// until it is available, and returns a function releasing it. It should be
+// held around every read-modify-write of a journal file, so that the CLI
+// and a running web server don't lose each other's updates. Locks are per
+// open file, so it must not be taken again while held, even by the same
+// process.
+func lockJournal(path string) (func(), error) {
+	// lock the directory itself so that there is no lock file to sync
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = flock(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		funlock(f)
+		f.Close()
+	}, nil
+}
+
+// atomicTempIgnore matches the temporary files of writeFileAtomic, for
+// .gitignore.
+const atomicTempIgnore = ".*.tmp[0-9]*"
+
+// writeFileAtomic replaces name with the contents of r by writing to a
+// temporary file in the same directory and renaming it over name, so that
+// readers see either the old or new contents and never a partial file.
+func writeFileAtomic(name string, r io.Reader) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+
+	_, err = io.Copy(f, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
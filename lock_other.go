@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Advisory locking isn't supported here, so concurrent updates rely on
+// writeFileAtomic alone.
+
+func flock(f *os.File) error {
+	return nil
+}
+
+func funlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// noteTime is the layout of the timestamp on each note.
+const noteTime = "15:04"
+
+// note appends a timestamped line to an entry without opening the editor,
+// for scripts and hooks. The text is read from stdin if it is "-".
+func note(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("note", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fDate := fs.String("d", "today", "date of the entry to add to")
+
+	err = fs.Parse(x)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: note [-d date] <text>, or - to read from stdin")
+	}
+
+	t, rest, err := parseDateArg(path, strings.Fields(*fDate))
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("invalid date: %v", *fDate)
+	}
+
+	var text string
+	if fs.NArg() == 1 && fs.Arg(0) == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	} else {
+		text = strings.Join(fs.Args(), " ")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("must provide note text")
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	err = appendEntry(path, dayPath(path, t), time.Now().Format(noteTime)+" "+text)
+	if err != nil {
+		return err
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return nil
+}
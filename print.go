@@ -2,11 +2,8 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -30,70 +27,37 @@ func printEntry(path string, x []string) error {
 	}
 
 	if len(x) == 0 {
-		return fmt.Errorf("command required. Options are:\n%v\n%v", printCommands, dateHelp)
+		return fmt.Errorf("command required. Options are:\n%v\n%v\n%v", printCommands, dateHelp, rangeHelp)
 	}
 
-	var year, month, day int
-
-	// check for a specific date first
-	if f := strings.Split(x[0], "/"); len(f) == 3 {
-		year, err = strconv.Atoi(f[0])
-		if err != nil {
-			return fmt.Errorf("invalid year: %v: %v", f[0], err)
-		}
-		month, err = strconv.Atoi(f[1])
-		if err != nil {
-			return fmt.Errorf("invalid month: %v: %v", f[1], err)
-		}
-		day, err = strconv.Atoi(f[2])
-		if err != nil {
-			return fmt.Errorf("invalid day: %v: %v", f[2], err)
-		}
+	start, end, rest, err := parseRangeArg(path, x)
+	if err != nil {
+		return err
+	}
 
-	} else {
-		r, err := Apropos(x[0], printCommands.commands)
-		if err != nil {
-			// try alias lookup
-			aliasDate, aliasErr := aliasLookup(path, x[0])
-			if aliasErr != nil {
-				return aliasErr
-			}
-			if aliasDate != "" {
-				parts := strings.Split(aliasDate, "/")
-				year, _ = strconv.Atoi(parts[0])
-				month, _ = strconv.Atoi(parts[1])
-				day, _ = strconv.Atoi(parts[2])
-			} else {
-				return fmt.Errorf("%w\n%v", err, dateHelp)
-			}
-		} else {
-			when := time.Now()
-			switch r {
-			case "today":
-			case "yesterday":
-				when = when.Add(-24 * time.Hour)
-			case "tomorrow":
-				when = when.Add(24 * time.Hour)
-			}
-			year = when.Year()
-			month = int(when.Month())
-			day = when.Day()
-		}
+	if start.Equal(end) {
+		return printDate(path, dayPath(path, start), rest)
 	}
 
-	// validate
-	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	valid := t.Year() == year && t.Month() == time.Month(month) && t.Day() == day
-	if !valid {
-		return fmt.Errorf("invalid date: %v/%v/%v", year, month, day)
+	return printRange(path, start, end, rest)
+}
+
+func printDate(path string, datePath string, x []string) error {
+	if len(x) != 0 {
+		return fmt.Errorf("trailing commands: %v", x)
 	}
 
-	datePath := filepath.Join(path, fmt.Sprintf("%v/%v/%v", year, month, day))
+	err := syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-	return printDate(path, datePath, x[1:])
+	return printDay(path, datePath)
 }
 
-func printDate(path string, datePath string, x []string) error {
+// printRange prints every non-empty entry between start and end (inclusive)
+// in chronological order, each under a date header.
+func printRange(path string, start, end time.Time, x []string) error {
 	if len(x) != 0 {
 		return fmt.Errorf("trailing commands: %v", x)
 	}
@@ -103,12 +67,35 @@ func printDate(path string, datePath string, x []string) error {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	f, err := os.Open(filepath.Join(datePath, entryName))
+	first := true
+	for _, e := range entryDates(path) {
+		t, err := parseDate(e, start)
+		if err != nil || t.Before(start) || t.After(end) {
+			continue
+		}
+
+		if !first {
+			fmt.Println()
+		}
+		first = false
+
+		fmt.Printf("=== %v %v ===\n", e, t.Weekday())
+		err = printDay(path, dayPath(path, t))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printDay writes a day's entry and any attached files to stdout.
+func printDay(path, datePath string) error {
+	data, err := readJournalFile(path, filepath.Join(datePath, entryName))
 	if err != nil {
 		return err
 	}
-	io.Copy(os.Stdout, f)
-	f.Close()
+	os.Stdout.Write(data)
 
 	// list any attached files
 	files, err := listFilesInDay(datePath)
@@ -1,54 +1,284 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
-	"io/fs"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
-var searchTerm string
+const searchUsage = `usage: search [-i] [-C lines] [-r range] [-t tag] <regexp>
+       search -q [-r range] [-t tag] <words or "phrase">`
 
+type searchOptions struct {
+	re      *regexp.Regexp
+	context int
+
+	// restrict to entries between start and end (inclusive), if set
+	start, end time.Time
+
+	// restrict to entries with a tag, if set
+	tagged map[string]bool
+
+	// restrict to entries within a directory of the journal, such as a
+	// year, if set
+	dir string
+
+	// printed ahead of every date, such as the journal's name when
+	// searching several
+	prefix string
+}
+
+// searchLine is a single line of search output, either a match or context
+// surrounding one.
+type searchLine struct {
+	date  string
+	no    int
+	text  string
+	match bool
+}
+
+// search searches any path: a journal, a directory within one such as a year,
+// or a directory holding journals, such as the base directory, which searches
+// each of them.
 func search(path string, x []string) error {
-	// no need to validate because we support any path
+	if isJournal(journalRoot(path)) {
+		return searchJournal(path, "", x)
+	}
+
+	journals := journalsIn(path)
+	if len(journals) == 0 {
+		return fmt.Errorf("not a journal or a directory within one: %v", path)
+	}
+	for _, j := range journals {
+		err := searchJournal(filepath.Join(path, j), j+"/", x)
+		if err != nil {
+			return fmt.Errorf("%v: %w", j, err)
+		}
+	}
+	return nil
+}
+
+// searchJournal searches the journal at path, or a directory within it,
+// printing prefix ahead of every date.
+func searchJournal(path, prefix string, x []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fInsensitive := fs.Bool("i", false, "case-insensitive search")
+	fContext := fs.Int("C", 0, "lines of context around each match")
+	fRange := fs.String("r", "", "restrict search to a date range")
+	fQuery := fs.Bool("q", false, "ranked word and phrase query using the index")
+	fTag := fs.String("t", "", "restrict search to entries with a #tag or @mention")
+
+	err := fs.Parse(x)
+	if err != nil {
+		return fmt.Errorf("%w\n%v", err, searchUsage)
+	}
+	x = fs.Args()
 
 	if len(x) == 0 {
-		return fmt.Errorf("search term required")
+		return fmt.Errorf("search term required\n%v", searchUsage)
+	}
+
+	o := &searchOptions{
+		context: max(*fContext, 0),
+		prefix:  prefix,
+	}
+
+	root := journalRoot(path)
+	if root != path {
+		o.dir, err = filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		o.dir = filepath.ToSlash(o.dir)
+		path = root
+	}
+
+	if *fRange != "" {
+		var rest []string
+		o.start, o.end, rest, err = parseRangeArg(path, strings.Fields(*fRange))
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("invalid range: %v", *fRange)
+		}
+	}
+
+	if *fTag != "" {
+		dates, err := taggedDates(path, *fTag)
+		if err != nil {
+			return err
+		}
+		o.tagged = make(map[string]bool)
+		for _, v := range dates {
+			o.tagged[v] = true
+		}
+	}
+
+	if *fQuery {
+		return searchIndexed(path, strings.Join(x, " "), o)
 	}
 
 	if len(x) != 1 {
 		return fmt.Errorf("trailing commands: %v", x[1:])
 	}
 
-	searchTerm = x[0]
+	term := x[0]
+	if *fInsensitive {
+		term = "(?i)" + term
+	}
+	o.re, err = regexp.Compile(term)
+	if err != nil {
+		return fmt.Errorf("invalid search term: %w", err)
+	}
 
-	return filepath.WalkDir(path, searchFunc)
-}
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
-func searchFunc(path string, d fs.DirEntry, err error) error {
-	base := filepath.Base(path)
-	if base == entryName {
-		return searchEntry(path)
+	first := true
+	for _, e := range entryDates(path) {
+		if !o.inRange(e) {
+			continue
+		}
+		lines, err := searchEntry(path, e, o)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		printSearchLines(os.Stdout, lines, o, first)
+		first = false
 	}
+
 	return nil
 }
 
-func searchEntry(path string) error {
-	// grep!
+// searchIndexed prints the days matching an indexed query, best first, each
+// with the first line containing a query word.
+func searchIndexed(path, query string, o *searchOptions) error {
+	// the index lives in the journal, so unlike a plain search this needs one
+	err := validate(path)
+	if err != nil {
+		return err
+	}
 
-	cmd := exec.Command("grep", "-H", searchTerm, path)
-	cmd.Stdout = os.Stdout
-	cmd.Env = os.Environ()
-	err := cmd.Run()
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	results, err := indexSearch(path, query, o)
 	if err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			if exiterr.ExitCode() == 1 {
-				// ignore, this just means that no lines were cu if exiterr, ok := err.(*exec.ExitError); ok {
-				return nil
-			}
-		}
 		return err
 	}
+
+	words, _ := parseQuery(query)
+	for _, r := range results {
+		fmt.Printf("%v%v (%.2f): %v\n", o.prefix, r.date, r.score, searchSnippet(path, r.date, words))
+	}
 	return nil
 }
+
+// searchSnippet returns the first line of an entry containing any of words.
+func searchSnippet(path, date string, words []string) string {
+	data, err := readJournalFile(path, filepath.Join(path, date, entryName))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		for _, t := range tokenize(line) {
+			if slices.Contains(words, t) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+// inRange reports whether the entry date falls within the search range and
+// directory, and has the tag searched for.
+func (o *searchOptions) inRange(date string) bool {
+	if o.tagged != nil && !o.tagged[date] {
+		return false
+	}
+	if o.dir != "" && date != o.dir && !strings.HasPrefix(date, o.dir+"/") {
+		return false
+	}
+	if o.start.IsZero() {
+		return true
+	}
+	t, err := parseDate(date, o.start)
+	if err != nil {
+		return false
+	}
+	return !t.Before(o.start) && !t.After(o.end)
+}
+
+// searchEntry returns the matching lines, and any requested context, in a
+// single day's entry.
+func searchEntry(path, date string, o *searchOptions) ([]searchLine, error) {
+	data, err := readJournalFile(path, filepath.Join(path, date, entryName))
+	if err != nil {
+		return nil, err
+	}
+
+	var text []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// the entry is already in memory, so allow a line as long as all of it
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		text = append(text, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	match := make([]bool, len(text))
+	show := make([]bool, len(text))
+	for i, v := range text {
+		if !o.re.MatchString(v) {
+			continue
+		}
+		match[i] = true
+		for j := max(i-o.context, 0); j <= min(i+o.context, len(text)-1); j++ {
+			show[j] = true
+		}
+	}
+
+	var lines []searchLine
+	for i, v := range text {
+		if show[i] {
+			lines = append(lines, searchLine{date: date, no: i + 1, text: v, match: match[i]})
+		}
+	}
+
+	return lines, nil
+}
+
+// printSearchLines prints lines grep-style: "date:line:text" for matches and
+// "date-line-text" for context. When showing context, groups of lines that
+// aren't adjacent are separated by "--".
+func printSearchLines(w io.Writer, lines []searchLine, o *searchOptions, first bool) {
+	for i, l := range lines {
+		if o.context > 0 && ((i == 0 && !first) || (i > 0 && l.no != lines[i-1].no+1)) {
+			fmt.Fprintln(w, "--")
+		}
+		sep := "-"
+		if l.match {
+			sep = ":"
+		}
+		fmt.Fprintf(w, "%v%v%v%v%v%v\n", o.prefix, l.date, sep, l.no, sep, l.text)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchEntry(t *testing.T) {
+	path := t.TempDir()
+	day := filepath.Join(path, "2026", "1", "6")
+	if err := os.MkdirAll(day, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// longer than bufio.Scanner's default limit
+	long := strings.Repeat("x", 100*1024) + " needle"
+	text := "one\ntwo needle\nthree\nfour\n" + long + "\n"
+	if err := os.WriteFile(filepath.Join(day, entryName), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &searchOptions{re: regexp.MustCompile("needle"), context: 1}
+	lines, err := searchEntry(path, "2026/1/6", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, l := range lines {
+		got = append(got, l.no)
+		if l.match != strings.Contains(l.text, "needle") {
+			t.Fatal("invalid match", l.no, l.match)
+		}
+	}
+	if len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Fatal("invalid lines", got)
+	}
+	if lines[4].text != long {
+		t.Fatal("invalid long line", len(lines[4].text))
+	}
+}
+
+func TestPrintSearchLines(t *testing.T) {
+	lines := []searchLine{
+		{date: "2026/1/6", no: 1, text: "one"},
+		{date: "2026/1/6", no: 2, text: "two needle", match: true},
+		{date: "2026/1/6", no: 5, text: "five needle", match: true},
+	}
+
+	var b strings.Builder
+	printSearchLines(&b, lines, &searchOptions{context: 1}, false)
+
+	want := "--\n2026/1/6-1-one\n2026/1/6:2:two needle\n--\n2026/1/6:5:five needle\n"
+	if b.String() != want {
+		t.Fatalf("invalid output:\n%v", b.String())
+	}
+
+	b.Reset()
+	printSearchLines(&b, lines[1:2], &searchOptions{}, false)
+	if b.String() != "2026/1/6:2:two needle\n" {
+		t.Fatalf("invalid output:\n%v", b.String())
+	}
+}
+
+func TestSearchInRangeDir(t *testing.T) {
+	o := &searchOptions{dir: "2026/1"}
+
+	for date, want := range map[string]bool{
+		"2026/1/6":  true,
+		"2026/1/31": true,
+		"2026/10/1": false,
+		"2025/1/6":  false,
+	} {
+		if o.inRange(date) != want {
+			t.Errorf("%v: expected %v", date, want)
+		}
+	}
+}
+
+func TestSearchDirectories(t *testing.T) {
+	base := t.TempDir()
+	for _, j := range []string{"work", "home/notes"} {
+		p := filepath.Join(base, filepath.FromSlash(j))
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(p, tagebuchMagic), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := journalsIn(base)
+	if !slices.Equal(got, []string{"home/notes", "work"}) {
+		t.Fatal("invalid journals", got)
+	}
+
+	if err := search(filepath.Join(base, "home", "other"), []string{"x"}); err == nil {
+		t.Fatal("expected error for a directory without journals")
+	}
+}
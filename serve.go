@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 func serve(path string, x []string) error {
@@ -17,28 +22,232 @@ func serve(path string, x []string) error {
 
 	hostPort := x[0]
 
-	// Validate that the journal exists
-	if err := validate(path); err != nil {
+	server, err := newTodoServer(path, filepath.Base(path), nil)
+	if err != nil {
+		return err
+	}
+
+	c, err := getConfig(path)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := serveTLSConfig(c, hostPort)
+	if err != nil {
+		return err
+	}
+
+	if !isLoopback(hostPort) {
+		if !server.auth.required() {
+			fmt.Fprintf(os.Stderr, "warning: anyone who can reach %v can read and change the journal. Set %v or %v.\n", hostPort, configServePassword, configServeToken)
+		} else if tlsConfig == nil {
+			fmt.Fprintf(os.Stderr, "warning: passwords are sent unencrypted. Set %v.\n", configServeTLS)
+		}
+	}
+
+	return listenAndServe(hostPort, server.handler(), tlsConfig, []*todoServer{server})
+}
+
+// serveAll serves every journal, each under /j/<journal>/ with its own
+// password or token, and a list of them at /. The list and any journal
+// without credentials of its own use those in the user config.
+func serveAll(x []string) error {
+	if len(x) != 1 {
+		return fmt.Errorf("serve requires 1 argument: <host:port>")
+	}
+
+	hostPort := x[0]
+
+	names := findJournals()
+	if len(names) == 0 {
+		return fmt.Errorf("no journals found in %v", baseDir)
+	}
+
+	c := userConfig
+	if c == nil {
+		c = defaultConfig()
+	}
+	tlsConfig, err := serveTLSConfig(c, hostPort)
+	if err != nil {
 		return err
 	}
 
-	server := &todoServer{
-		path: path,
+	js := &journalsServer{
+		auth:     serveAuth{token: c.serveToken, password: c.servePassword, realm: "tb"},
+		journals: make(map[string]http.Handler),
+		names:    names,
+	}
+	var open []string
+	for _, name := range names {
+		server, err := newTodoServer(filepath.Join(baseDir, name), name, names)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		js.journals[name] = http.StripPrefix("/j/"+name, server.handler())
+		js.servers = append(js.servers, server)
+		if !server.auth.required() {
+			open = append(open, name)
+		}
+	}
+
+	if !isLoopback(hostPort) {
+		if len(open) != 0 {
+			fmt.Fprintf(os.Stderr, "warning: anyone who can reach %v can read and change %v. Set %v or %v.\n", hostPort, strings.Join(open, ", "), configServePassword, configServeToken)
+		} else if tlsConfig == nil {
+			fmt.Fprintf(os.Stderr, "warning: passwords are sent unencrypted. Set %v.\n", configServeTLS)
+		}
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /", server.serveHTML)
-	mux.HandleFunc("GET /api/todos", server.getTodos)
-	mux.HandleFunc("POST /api/todos", server.addTodo)
-	mux.HandleFunc("DELETE /api/todos/{id}", server.removeTodo)
-	mux.HandleFunc("POST /api/sync", server.doSync)
-
-	fmt.Printf("Starting todo server at http://%s\n", hostPort)
-	return http.ListenAndServe(hostPort, mux)
+	mux.Handle("GET /{$}", js.auth.authenticate(http.HandlerFunc(js.serveList)))
+	mux.Handle("GET /api/journals", js.auth.authenticate(http.HandlerFunc(js.getJournals)))
+	mux.Handle("GET /api/status", js.auth.authenticate(http.HandlerFunc(js.getStatus)))
+	mux.HandleFunc("/j/", js.serveJournal)
+
+	return listenAndServe(hostPort, mux, tlsConfig, js.servers)
+}
+
+// listenAndServe serves h, logging each request, and syncs the journals
+// served in the background. On SIGINT or SIGTERM it lets requests in
+// progress finish, and pushes each journal before returning.
+func listenAndServe(hostPort string, h http.Handler, tlsConfig *tls.Config, servers []*todoServer) error {
+	srv := &http.Server{
+		Addr:      hostPort,
+		Handler:   logRequests(protectCSRF(h)),
+		TLSConfig: tlsConfig,
+	}
+	srv.RegisterOnShutdown(func() {
+		for _, ts := range servers {
+			ts.stopSync()
+		}
+	})
+
+	// every journal's passphrase was asked for by newTodoServer, and the
+	// terminal isn't watched from here on
+	noPassphrasePrompt.Store(true)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			fmt.Printf("Starting journal server at https://%s\n", hostPort)
+			errc <- srv.ListenAndServeTLS("", "")
+		} else {
+			fmt.Printf("Starting journal server at http://%s\n", hostPort)
+			errc <- srv.ListenAndServe()
+		}
+	}()
+
+	for _, ts := range servers {
+		ts.startSync()
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// a second signal stops at once
+	stop()
+
+	fmt.Println("Shutting down")
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	for _, ts := range servers {
+		ts.finishSync()
+	}
+	return nil
 }
 
 type todoServer struct {
-	path string
+	path     string
+	name     string
+	journals []string // every journal being served, if more than this one
+	auth     serveAuth
+	events   *eventHub
+	sync     *serveSync
+}
+
+// newTodoServer prepares to serve the journal at path, asking for its
+// passphrase now rather than on the first request.
+func newTodoServer(path, name string, journals []string) (*todoServer, error) {
+	if err := validate(path); err != nil {
+		return nil, err
+	}
+
+	enc, err := encryptEnabled(path)
+	if err != nil {
+		return nil, err
+	}
+	if enc {
+		if _, err := journalKey(path); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := getConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &todoServer{
+		path:     path,
+		name:     name,
+		journals: journals,
+		auth: serveAuth{
+			token:    c.serveToken,
+			password: c.servePassword,
+			realm:    "tb " + name,
+		},
+		events: newEventHub(),
+		sync:   newServeSync(c),
+	}
+
+	// without a watcher the page still works, but only shows its own
+	// changes
+	if err := watchJournal(path, ts.events); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: not watching %v for changes: %v\n", name, err)
+	}
+
+	return ts, nil
+}
+
+// handler routes the journal's pages and API, relative to where it is
+// served.
+func (ts *todoServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", ts.serveHTML)
+	mux.HandleFunc("GET /api/journal", ts.getJournal)
+	mux.HandleFunc("GET /api/events", ts.streamEvents)
+	mux.HandleFunc("GET /api/status", ts.getStatus)
+	mux.HandleFunc("GET /api/todos", ts.getTodos)
+	mux.HandleFunc("POST /api/todos", ts.addTodo)
+	mux.HandleFunc("DELETE /api/todos/{id}", ts.removeTodo)
+	mux.HandleFunc("POST /api/sync", ts.doSync)
+	mux.HandleFunc("GET /api/entries/{y}/{m}/{d}", ts.getEntry)
+	mux.HandleFunc("PUT /api/entries/{y}/{m}/{d}", ts.putEntry)
+	mux.HandleFunc("GET /api/calendar/{y}/{m}", ts.getCalendar)
+	mux.HandleFunc("GET /api/files/{y}/{m}/{d}", ts.listFiles)
+	mux.HandleFunc("POST /api/files/{y}/{m}/{d}", ts.uploadFiles)
+	mux.HandleFunc("GET /api/files/{y}/{m}/{d}/{name}", ts.getFile)
+	mux.HandleFunc("DELETE /api/files/{y}/{m}/{d}/{name}", ts.removeFile)
+	mux.HandleFunc("GET /api/aliases", ts.getAliases)
+	mux.HandleFunc("POST /api/aliases", ts.addAlias)
+	mux.HandleFunc("DELETE /api/aliases/{name}", ts.removeAlias)
+	return ts.auth.authenticate(mux)
+}
+
+func (ts *todoServer) getJournal(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":     ts.name,
+		"journals": append([]string{}, ts.journals...),
+	})
 }
 
 func (ts *todoServer) serveHTML(w http.ResponseWriter, r *http.Request) {
@@ -47,8 +256,10 @@ func (ts *todoServer) serveHTML(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ts *todoServer) getTodos(w http.ResponseWriter, r *http.Request) {
-	t, err := todoLoad(ts.path)
-	if err != nil {
+	t, err := todoRead(ts.path, tagebuchTodo)
+	if errors.Is(err, os.ErrNotExist) {
+		t = &todos{}
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -84,14 +295,18 @@ func (ts *todoServer) addTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ts *todoServer) removeTodo(w http.ResponseWriter, r *http.Request) {
-	idStr := r.PathValue("id")
-	_, err := strconv.Atoi(idStr)
-	if err != nil {
+	id := r.PathValue("id")
+	if id == "" {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
 
-	if err := todoComplete(ts.path, []string{idStr}); err != nil {
+	if err := completeTodos(ts.path, []string{id}, true); err != nil {
+		if errors.Is(err, ErrTodoNotFound) {
+			// most likely already completed elsewhere
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -101,11 +316,10 @@ func (ts *todoServer) removeTodo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ts *todoServer) doSync(w http.ResponseWriter, r *http.Request) {
-	if err := doGitPull(ts.path); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-	}
-	if err := doGitPush(ts.path); err != nil {
+	if err := ts.syncNow(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -117,7 +331,7 @@ const htmlTemplate = `<!DOCTYPE html>
 <head>
 	<meta charset="UTF-8">
 	<meta name="viewport" content="width=device-width, initial-scale=1.0">
-	<title>Todo List</title>
+	<title>Journal</title>
 	<style>
 		* {
 			margin: 0;
@@ -132,7 +346,7 @@ const htmlTemplate = `<!DOCTYPE html>
 		}
 
 		.container {
-			max-width: 600px;
+			max-width: 800px;
 			margin: 0 auto;
 			background: white;
 			border-radius: 8px;
@@ -140,10 +354,53 @@ const htmlTemplate = `<!DOCTYPE html>
 			padding: 20px;
 		}
 
+		header {
+			display: flex;
+			align-items: center;
+			gap: 10px;
+			margin-bottom: 20px;
+		}
+
 		h1 {
 			color: #333;
-			margin-bottom: 20px;
 			font-size: 24px;
+			flex: 1;
+		}
+
+		h2 {
+			color: #333;
+			font-size: 18px;
+			margin: 20px 0 10px;
+		}
+
+		.tabs {
+			display: flex;
+			gap: 4px;
+			border-bottom: 1px solid #ddd;
+			margin-bottom: 20px;
+		}
+
+		.tabs button {
+			background: none;
+			color: #555;
+			border-radius: 4px 4px 0 0;
+		}
+
+		.tabs button:hover {
+			background-color: #eee;
+		}
+
+		.tabs button.active {
+			color: #333;
+			border-bottom: 2px solid #4CAF50;
+		}
+
+		.view {
+			display: none;
+		}
+
+		.view.active {
+			display: block;
 		}
 
 		.input-group {
@@ -160,11 +417,32 @@ const htmlTemplate = `<!DOCTYPE html>
 			font-size: 14px;
 		}
 
-		input[type="text"]:focus {
+		input[type="text"]:focus, textarea:focus {
 			outline: none;
 			border-color: #4CAF50;
 		}
 
+		textarea {
+			width: 100%;
+			min-height: 300px;
+			padding: 10px;
+			border: 1px solid #ddd;
+			border-radius: 4px;
+			font-family: ui-monospace, Menlo, Consolas, monospace;
+			font-size: 14px;
+			line-height: 1.5;
+			resize: vertical;
+			margin-bottom: 10px;
+		}
+
+		select {
+			padding: 9px;
+			border: 1px solid #ddd;
+			border-radius: 4px;
+			font-size: 14px;
+			background: white;
+		}
+
 		button {
 			padding: 10px 20px;
 			background-color: #4CAF50;
@@ -188,7 +466,87 @@ const htmlTemplate = `<!DOCTYPE html>
 			background-color: #0b7dda;
 		}
 
-		.todo-list {
+		button.nav {
+			background-color: #eee;
+			color: #333;
+		}
+
+		button.nav:hover {
+			background-color: #ddd;
+		}
+
+		.sync-info {
+			color: #999;
+			font-size: 12px;
+		}
+
+		.sync-info.error {
+			color: #f44336;
+		}
+
+		.cal-nav {
+			display: flex;
+			align-items: center;
+			gap: 10px;
+			margin-bottom: 10px;
+		}
+
+		.cal-nav span {
+			flex: 1;
+			text-align: center;
+			font-weight: bold;
+			color: #333;
+		}
+
+		table.calendar {
+			width: 100%;
+			border-collapse: collapse;
+			table-layout: fixed;
+		}
+
+		.calendar th {
+			color: #999;
+			font-weight: normal;
+			font-size: 12px;
+			padding: 6px;
+		}
+
+		.calendar td {
+			border: 1px solid #eee;
+			height: 48px;
+			text-align: center;
+			color: #333;
+		}
+
+		.calendar td.day {
+			cursor: pointer;
+		}
+
+		.calendar td.day:hover {
+			background-color: #f0f0f0;
+		}
+
+		.calendar td.entry {
+			color: #2e7d32;
+			font-weight: bold;
+			background-color: #e8f5e9;
+		}
+
+		.calendar td.files::after {
+			content: '*';
+			color: #2196F3;
+		}
+
+		.calendar td.today {
+			outline: 2px solid #2196F3;
+			outline-offset: -2px;
+		}
+
+		.calendar td.selected {
+			background-color: #c8e6c9;
+		}
+
+		.todo-list, .file-list, .alias-list {
 			list-style: none;
 		}
 
@@ -196,6 +554,7 @@ const htmlTemplate = `<!DOCTYPE html>
 			display: flex;
 			justify-content: space-between;
 			align-items: center;
+			gap: 10px;
 			padding: 12px;
 			border-bottom: 1px solid #eee;
 		}
@@ -207,6 +566,12 @@ const htmlTemplate = `<!DOCTYPE html>
 		.todo-text {
 			flex: 1;
 			color: #333;
+			overflow-wrap: anywhere;
+		}
+
+		.todo-text a {
+			color: #0b7dda;
+			text-decoration: none;
 		}
 
 		.todo-delete {
@@ -252,25 +617,248 @@ const htmlTemplate = `<!DOCTYPE html>
 </head>
 <body>
 	<div class="container">
-		<h1>Todo List</h1>
+		<header>
+			<h1 id="journalName">Journal</h1>
+			<select id="journalPicker" onchange="location.href = '/j/' + encodeURI(this.value) + '/'" style="display: none"></select>
+			<span id="syncInfo" class="sync-info"></span>
+			<button class="sync" onclick="syncAll()">Sync</button>
+		</header>
 		<div id="status" class="status"></div>
-		<div class="input-group">
-			<input type="text" id="todoInput" placeholder="Add a new todo..." />
-			<button onclick="addTodo()">Add</button>
-			<button class="sync" onclick="syncTodos()">Sync</button>
+		<div class="tabs">
+			<button id="tab-calendar" onclick="showView('calendar')">Calendar</button>
+			<button id="tab-todos" onclick="showView('todos')">Todos</button>
+			<button id="tab-aliases" onclick="showView('aliases')">Aliases</button>
+		</div>
+
+		<div id="view-calendar" class="view">
+			<div class="cal-nav">
+				<button class="nav" onclick="moveMonth(-1)">&lsaquo;</button>
+				<span id="calTitle"></span>
+				<button class="nav" onclick="moveMonth(1)">&rsaquo;</button>
+				<button class="nav" onclick="openDay(todayString())">Today</button>
+			</div>
+			<table class="calendar">
+				<thead><tr><th>Su</th><th>Mo</th><th>Tu</th><th>We</th><th>Th</th><th>Fr</th><th>Sa</th></tr></thead>
+				<tbody id="calBody"></tbody>
+			</table>
+
+			<div id="day" style="display: none">
+				<h2 id="dayTitle"></h2>
+				<textarea id="entryText" placeholder="Write today's entry..."></textarea>
+				<div class="input-group">
+					<button onclick="saveEntry()">Save</button>
+				</div>
+				<h2>Files</h2>
+				<ul id="fileList" class="file-list"></ul>
+				<div class="input-group">
+					<input type="file" id="fileInput" multiple />
+					<button onclick="uploadFiles()">Upload</button>
+				</div>
+			</div>
+		</div>
+
+		<div id="view-todos" class="view">
+			<div class="input-group">
+				<input type="text" id="todoInput" placeholder="Add a new todo..." />
+				<button onclick="addTodo()">Add</button>
+			</div>
+			<ul id="todoList" class="todo-list">
+				<li class="empty">Loading...</li>
+			</ul>
+		</div>
+
+		<div id="view-aliases" class="view">
+			<div class="input-group">
+				<input type="text" id="aliasName" placeholder="Name, e.g. launch" />
+				<input type="text" id="aliasDate" placeholder="Date, e.g. 2026/1/6 or yesterday" />
+				<button onclick="addAlias()">Add</button>
+			</div>
+			<ul id="aliasList" class="alias-list">
+				<li class="empty">Loading...</li>
+			</ul>
 		</div>
-		<ul id="todoList" class="todo-list">
-			<li class="empty">Loading...</li>
-		</ul>
 	</div>
 
 	<script>
+		let view = 'calendar';
+		let month = null; // {year, month}
+		let day = null; // the open day, year/month/day
+		let saved = ''; // the open entry as last loaded or saved
+		let template = ''; // the template offered for a new entry
+
+		function todayString() {
+			const d = new Date();
+			return d.getFullYear() + '/' + (d.getMonth() + 1) + '/' + d.getDate();
+		}
+
+		function dayURL(date) {
+			return date.split('/').map(encodeURIComponent).join('/');
+		}
+
+		function unsaved() {
+			return day && document.getElementById('entryText').value !== saved &&
+				!(saved === '' && document.getElementById('entryText').value === template);
+		}
+
+		function showView(name) {
+			view = name;
+			for (const v of ['calendar', 'todos', 'aliases']) {
+				document.getElementById('view-' + v).classList.toggle('active', v === name);
+				document.getElementById('tab-' + v).classList.toggle('active', v === name);
+			}
+			if (name === 'todos') loadTodos();
+			if (name === 'aliases') loadAliases();
+			if (name === 'calendar') loadCalendar();
+		}
+
+		async function request(method, url, body) {
+			const opts = { method };
+			if (body instanceof FormData) {
+				opts.body = body;
+			} else if (body !== undefined) {
+				opts.headers = { 'Content-Type': 'application/json' };
+				opts.body = JSON.stringify(body);
+			}
+			const res = await fetch(url, opts);
+			if (!res.ok) {
+				const err = new Error((await res.text()).trim() || res.statusText);
+				err.status = res.status;
+				throw err;
+			}
+			return res.json();
+		}
+
+		// calendar
+
+		function moveMonth(n) {
+			const d = new Date(month.year, month.month - 1 + n, 1);
+			month = { year: d.getFullYear(), month: d.getMonth() + 1 };
+			loadCalendar();
+		}
+
+		async function loadCalendar() {
+			if (!month) {
+				const d = new Date();
+				month = { year: d.getFullYear(), month: d.getMonth() + 1 };
+			}
+			try {
+				const cal = await request('GET', 'api/calendar/' + month.year + '/' + month.month);
+				renderCalendar(cal);
+			} catch (err) {
+				showStatus('Failed to load calendar: ' + err.message, 'error');
+			}
+		}
+
+		function renderCalendar(cal) {
+			document.getElementById('calTitle').textContent = cal.title;
+			const today = todayString();
+			let html = '<tr>';
+			for (let i = 0; i < cal.first_weekday; i++) html += '<td></td>';
+			for (let d = 1; d <= cal.days; d++) {
+				const date = cal.year + '/' + cal.month + '/' + d;
+				const classes = ['day'];
+				if (cal.entries.includes(d)) classes.push('entry');
+				if (cal.files.includes(d)) classes.push('files');
+				if (date === today) classes.push('today');
+				if (date === day) classes.push('selected');
+				html += '<td class="' + classes.join(' ') + '" onclick="openDay(\'' + date + '\')">' + d + '</td>';
+				if ((cal.first_weekday + d) % 7 === 0 && d < cal.days) html += '</tr><tr>';
+			}
+			for (let i = (cal.first_weekday + cal.days) % 7; i > 0 && i < 7; i++) html += '<td></td>';
+			document.getElementById('calBody').innerHTML = html + '</tr>';
+		}
+
+		async function openDay(date) {
+			if (date !== day && unsaved() && !confirm('Discard unsaved changes to ' + day + '?')) return;
+			try {
+				const entry = await request('GET', 'api/entries/' + dayURL(date) + '?template=1');
+				day = entry.date;
+				saved = entry.text;
+				template = entry.template || '';
+				const f = day.split('/');
+				month = { year: +f[0], month: +f[1] };
+				if (location.hash !== '#' + day) history.replaceState(null, '', '#' + day);
+
+				document.getElementById('day').style.display = 'block';
+				document.getElementById('dayTitle').textContent = entry.weekday + ', ' + entry.date;
+				document.getElementById('entryText').value = entry.text || template;
+				renderFiles(entry.files);
+				loadCalendar();
+			} catch (err) {
+				showStatus('Failed to load ' + date + ': ' + err.message, 'error');
+			}
+		}
+
+		async function saveEntry() {
+			if (!day) return;
+			let text = document.getElementById('entryText').value;
+			// an untouched template isn't an entry
+			if (saved === '' && text === template) text = '';
+			try {
+				await request('PUT', 'api/entries/' + dayURL(day), { text });
+				saved = text;
+				showStatus('Saved ' + day, 'success');
+				loadCalendar();
+			} catch (err) {
+				showStatus('Failed to save: ' + err.message, 'error');
+			}
+		}
+
+		function renderFiles(files) {
+			const list = document.getElementById('fileList');
+			if (!files || files.length === 0) {
+				list.innerHTML = '<li class="empty">No files</li>';
+				return;
+			}
+			list.innerHTML = files.map((name) => {
+				const url = 'api/files/' + dayURL(day) + '/' + encodeURIComponent(name);
+				return '<li class="todo-item"><span class="todo-text"><a href="' + url + '" target="_blank">' +
+					escapeHtml(name) +
+					'</a></span><button class="todo-delete" onclick="removeFile(\'' + encodeURIComponent(name) + '\')">Remove</button></li>';
+			}).join('');
+		}
+
+		async function loadFiles() {
+			try {
+				renderFiles(await request('GET', 'api/files/' + dayURL(day)));
+			} catch (err) {
+				showStatus('Failed to load files', 'error');
+			}
+		}
+
+		async function uploadFiles() {
+			const input = document.getElementById('fileInput');
+			if (!day || input.files.length === 0) return;
+			const form = new FormData();
+			for (const f of input.files) form.append('file', f);
+			try {
+				await request('POST', 'api/files/' + dayURL(day), form);
+				input.value = '';
+				showStatus('Uploaded', 'success');
+				loadFiles();
+				loadCalendar();
+			} catch (err) {
+				showStatus('Failed to upload: ' + err.message, 'error');
+			}
+		}
+
+		async function removeFile(name) {
+			if (!confirm('Remove ' + decodeURIComponent(name) + '?')) return;
+			try {
+				await request('DELETE', 'api/files/' + dayURL(day) + '/' + name);
+				showStatus('File removed', 'success');
+			} catch (err) {
+				showStatus('Failed to remove file: ' + err.message, 'error');
+			}
+			loadFiles();
+			loadCalendar();
+		}
+
+		// todos
+
 		async function loadTodos() {
 			try {
-				const res = await fetch('/api/todos');
-				if (!res.ok) throw new Error('Failed to load todos');
-				const todos = await res.json();
-				renderTodos(todos);
+				renderTodos(await request('GET', 'api/todos'));
 			} catch (err) {
 				showStatus('Failed to load todos', 'error');
 			}
@@ -282,10 +870,13 @@ const htmlTemplate = `<!DOCTYPE html>
 				list.innerHTML = '<li class="empty">No todos yet</li>';
 				return;
 			}
-			list.innerHTML = todos.map((todo, idx) => {
-				return '<li class="todo-item"><span class="todo-text">' + 
-					escapeHtml(todo) + 
-					'</span><button class="todo-delete" onclick="removeTodo(' + idx + ')">Remove</button></li>';
+			list.innerHTML = todos.map((todo) => {
+				let text = todo.text;
+				if (todo.priority) text = '(' + todo.priority + ') ' + text;
+				if (todo.due) text += ' due:' + todo.due;
+				return '<li class="todo-item"><span class="todo-text">' +
+					escapeHtml(text) +
+					'</span><button class="todo-delete" onclick="removeTodo(\'' + encodeURIComponent(todo.id) + '\')">Remove</button></li>';
 			}).join('');
 		}
 
@@ -295,12 +886,7 @@ const htmlTemplate = `<!DOCTYPE html>
 			if (!text) return;
 
 			try {
-				const res = await fetch('/api/todos', {
-					method: 'POST',
-					headers: { 'Content-Type': 'application/json' },
-					body: JSON.stringify({ text })
-				});
-				if (!res.ok) throw new Error('Failed to add todo');
+				await request('POST', 'api/todos', { text });
 				input.value = '';
 				showStatus('Todo added', 'success');
 				loadTodos();
@@ -311,28 +897,146 @@ const htmlTemplate = `<!DOCTYPE html>
 
 		async function removeTodo(id) {
 			try {
-				const res = await fetch('/api/todos/' + id, {
-					method: 'DELETE'
-				});
-				if (!res.ok) throw new Error('Failed to remove todo');
+				await request('DELETE', 'api/todos/' + id);
 				showStatus('Todo removed', 'success');
-				loadTodos();
 			} catch (err) {
-				showStatus('Failed to remove todo', 'error');
+				if (err.status === 404) {
+					showStatus('Todo was already removed', 'error');
+				} else {
+					showStatus('Failed to remove todo', 'error');
+				}
+			}
+			loadTodos();
+		}
+
+		// aliases
+
+		async function loadAliases() {
+			try {
+				renderAliases(await request('GET', 'api/aliases'));
+			} catch (err) {
+				showStatus('Failed to load aliases', 'error');
+			}
+		}
+
+		function renderAliases(aliases) {
+			const list = document.getElementById('aliasList');
+			const names = Object.keys(aliases || {}).sort();
+			if (names.length === 0) {
+				list.innerHTML = '<li class="empty">No aliases yet</li>';
+				return;
+			}
+			list.innerHTML = names.map((name) => {
+				const date = aliases[name];
+				return '<li class="todo-item"><span class="todo-text">' + escapeHtml(name) +
+					' &rarr; <a href="#' + escapeHtml(date) + '" onclick="showView(\'calendar\'); openDay(\'' + escapeHtml(date) + '\'); return false">' +
+					escapeHtml(date) + '</a></span><button class="todo-delete" onclick="removeAlias(\'' +
+					encodeURIComponent(name).replace(/'/g, '%27') + '\')">Remove</button></li>';
+			}).join('');
+		}
+
+		async function addAlias() {
+			const name = document.getElementById('aliasName');
+			const date = document.getElementById('aliasDate');
+			if (!name.value.trim() || !date.value.trim()) return;
+
+			try {
+				await request('POST', 'api/aliases', { name: name.value, date: date.value });
+				name.value = '';
+				date.value = '';
+				showStatus('Alias added', 'success');
+				loadAliases();
+			} catch (err) {
+				showStatus('Failed to add alias: ' + err.message.split('\n')[0], 'error');
+			}
+		}
+
+		async function removeAlias(name) {
+			try {
+				await request('DELETE', 'api/aliases/' + name);
+				showStatus('Alias removed', 'success');
+			} catch (err) {
+				showStatus('Failed to remove alias', 'error');
 			}
+			loadAliases();
 		}
 
-		async function syncTodos() {
+		async function loadJournal() {
 			try {
-				const res = await fetch('/api/sync', {
-					method: 'POST'
-				});
-				if (!res.ok) throw new Error('Failed to sync');
+				const j = await request('GET', 'api/journal');
+				document.getElementById('journalName').textContent = j.name;
+				document.title = j.name;
+				if (j.journals.length > 1) {
+					const picker = document.getElementById('journalPicker');
+					picker.innerHTML = j.journals.map((name) =>
+						'<option' + (name === j.name ? ' selected' : '') + '>' + escapeHtml(name) + '</option>').join('');
+					picker.style.display = 'block';
+				}
+			} catch (err) {
+				showStatus('Failed to load journal', 'error');
+			}
+		}
+
+		// changes made elsewhere, by the CLI or another browser
+
+		function watchChanges() {
+			const events = new EventSource('api/events');
+			events.addEventListener('change', (msg) => {
+				const e = JSON.parse(msg.data);
+				if (e.type === 'todos' && view === 'todos') loadTodos();
+				if (e.type === 'aliases' && view === 'aliases') loadAliases();
+				if (e.type === 'day' || e.type === 'journal') {
+					if (view === 'calendar') loadCalendar();
+					if (day && (e.type === 'journal' || e.date === day)) reloadDay();
+				}
+				if (e.type === 'journal' && view !== 'calendar') showView(view);
+			});
+		}
+
+		async function reloadDay() {
+			try {
+				const entry = await request('GET', 'api/entries/' + dayURL(day));
+				renderFiles(entry.files);
+				if (entry.text === saved) return;
+				if (unsaved()) {
+					showStatus(day + ' was changed elsewhere. Saving will replace those changes.', 'error');
+					return;
+				}
+				// the template offered when the day was opened still stands
+				saved = entry.text;
+				document.getElementById('entryText').value = entry.text || template;
+			} catch (err) {
+				showStatus('Failed to reload ' + day, 'error');
+			}
+		}
+
+		async function syncAll() {
+			try {
+				await request('POST', 'api/sync');
 				showStatus('Synced successfully', 'success');
-				loadTodos();
+				showView(view);
+				if (day && !unsaved()) openDay(day);
 			} catch (err) {
 				showStatus('Failed to sync', 'error');
 			}
+			loadStatus();
+		}
+
+		async function loadStatus() {
+			const info = document.getElementById('syncInfo');
+			try {
+				const s = await request('GET', 'api/status');
+				if (!s.last_sync) {
+					info.textContent = '';
+					return;
+				}
+				const when = new Date(s.last_sync).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
+				info.textContent = (s.last_error ? 'Sync failed at ' : 'Synced at ') + when;
+				info.title = s.last_error || '';
+				info.classList.toggle('error', !!s.last_error);
+			} catch (err) {
+				info.textContent = '';
+			}
 		}
 
 		function showStatus(msg, type) {
@@ -345,14 +1049,31 @@ const htmlTemplate = `<!DOCTYPE html>
 		function escapeHtml(text) {
 			const div = document.createElement('div');
 			div.textContent = text;
-			return div.innerHTML;
+			return div.innerHTML.replace(/"/g, '&quot;').replace(/'/g, '&#39;');
 		}
 
 		document.getElementById('todoInput').addEventListener('keypress', (e) => {
 			if (e.key === 'Enter') addTodo();
 		});
+		document.getElementById('aliasDate').addEventListener('keypress', (e) => {
+			if (e.key === 'Enter') addAlias();
+		});
+		document.getElementById('entryText').addEventListener('keydown', (e) => {
+			if ((e.ctrlKey || e.metaKey) && e.key === 's') {
+				e.preventDefault();
+				saveEntry();
+			}
+		});
+		window.addEventListener('beforeunload', (e) => {
+			if (unsaved()) e.preventDefault();
+		});
 
-		loadTodos();
+		loadJournal();
+		loadStatus();
+		setInterval(loadStatus, 60000);
+		watchChanges();
+		showView('calendar');
+		openDay(location.hash.length > 1 ? decodeURIComponent(location.hash.slice(1)) : todayString());
 	</script>
 </body>
 </html>
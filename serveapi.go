@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxUpload is the most the web UI may send in one request, entry or files.
+const maxUpload = 64 << 20
+
+// dayEntry is a day as served by /api/entries.
+type dayEntry struct {
+	Date     string   `json:"date"`
+	Weekday  string   `json:"weekday"`
+	Text     string   `json:"text"`
+	Files    []string `json:"files"`
+	Template string   `json:"template,omitempty"` // for a day without an entry, if asked for
+}
+
+// calendarView is a month as served by /api/calendar.
+type calendarView struct {
+	Year         int         `json:"year"`
+	Month        int         `json:"month"`
+	Title        string      `json:"title"`
+	FirstWeekday int         `json:"first_weekday"` // 0 is Sunday
+	Days         int         `json:"days"`
+	Entries      []int       `json:"entries"`
+	Files        []int       `json:"files"`
+	Heat         map[int]int `json:"heat,omitempty"`
+}
+
+// requestDay returns the day named by a request's y, m and d path values.
+func requestDay(r *http.Request) (time.Time, error) {
+	return parseYMD([]string{r.PathValue("y"), r.PathValue("m"), r.PathValue("d")})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError reports err with a status that suits it.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidDate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, os.ErrNotExist):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ts *todoServer) getEntry(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	d := &dayEntry{
+		Date:    dateString(t),
+		Weekday: t.Weekday().String(),
+		Files:   []string{},
+	}
+	d.Text, err = entryText(ts.path, d.Date)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		writeError(w, err)
+		return
+	}
+	files, err := listFilesInDay(dayPath(ts.path, t))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	d.Files = append(d.Files, files...)
+
+	// templates can walk the journal, so only when opening an empty day
+	if d.Text == "" && r.URL.Query().Get("template") == "1" {
+		name, err := templateName(ts.path, "")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if name != "" {
+			data, err := renderTemplate(ts.path, name, t)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			d.Template = string(data)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (ts *todoServer) putEntry(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	text := strings.ReplaceAll(req.Text, "\r\n", "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	if err := entrySave(ts.path, t, []byte(text)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (ts *todoServer) getCalendar(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.PathValue("y"))
+	if err != nil {
+		http.Error(w, "invalid year", http.StatusBadRequest)
+		return
+	}
+	month, err := strconv.Atoi(r.PathValue("m"))
+	if err != nil || month < 1 || month > 12 {
+		http.Error(w, "invalid month", http.StatusBadRequest)
+		return
+	}
+
+	o := &calendarOptions{
+		tag:    r.URL.Query().Get("tag"),
+		months: 1,
+		heat:   r.URL.Query().Get("heat") != "",
+	}
+	m, err := scanMonth(ts.path, year, month, o)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	c := &calendarView{
+		Year:         year,
+		Month:        month,
+		Title:        fmt.Sprintf("%v %v", first.Month(), year),
+		FirstWeekday: int(first.Weekday()),
+		Days:         daysIn(month, year),
+		Entries:      []int{},
+		Files:        []int{},
+		Heat:         m.heat,
+	}
+	for day := 1; day <= c.Days; day++ {
+		if m.entries[day] {
+			c.Entries = append(c.Entries, day)
+		}
+		if m.files[day] {
+			c.Files = append(c.Files, day)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (ts *todoServer) listFiles(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	files, err := listFilesInDay(dayPath(ts.path, t))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]string{}, files...))
+}
+
+func (ts *todoServer) getFile(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name := r.PathValue("name")
+	if !validFileName(name) {
+		http.Error(w, "invalid file name", http.StatusBadRequest)
+		return
+	}
+
+	filename := filepath.Join(dayPath(ts.path, t), name)
+	info, err := os.Stat(filename)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := readJournalFile(ts.path, filename)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// only images are shown in the browser, as anything else served from
+	// here, such as html, could act as the UI
+	ctype := mime.TypeByExtension(filepath.Ext(name))
+	if ctype == "" {
+		ctype = http.DetectContentType(data)
+	}
+	disposition := "attachment"
+	if strings.HasPrefix(ctype, "image/") && !strings.HasPrefix(ctype, "image/svg") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+}
+
+func (ts *todoServer) uploadFiles(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	if err := r.ParseMultipartForm(maxUpload); err != nil {
+		http.Error(w, "invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	var added []string
+	for _, fh := range r.MultipartForm.File["file"] {
+		name := filepath.Base(fh.Filename)
+		if !validFileName(name) {
+			http.Error(w, "invalid file name: "+fh.Filename, http.StatusBadRequest)
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := fileAdd(ts.path, t, name, data); err != nil {
+			writeError(w, err)
+			return
+		}
+		added = append(added, name)
+	}
+	if len(added) == 0 {
+		http.Error(w, "no files", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, added)
+}
+
+func (ts *todoServer) removeFile(w http.ResponseWriter, r *http.Request) {
+	t, err := requestDay(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name := r.PathValue("name")
+	if !validFileName(name) {
+		http.Error(w, "invalid file name", http.StatusBadRequest)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dayPath(ts.path, t), name)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := filesRemove(ts.path, []string{dateString(t), name}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (ts *todoServer) getAliases(w http.ResponseWriter, r *http.Request) {
+	a, err := aliasRead(ts.path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.a)
+}
+
+func (ts *todoServer) addAlias(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || strings.Contains(name, "=") || isBuiltinDate(name) {
+		http.Error(w, "invalid alias name", http.StatusBadRequest)
+		return
+	}
+	date := strings.Fields(req.Date)
+	if len(date) == 0 {
+		http.Error(w, "date required", http.StatusBadRequest)
+		return
+	}
+
+	if err := aliasAdd(ts.path, append([]string{name}, date...)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"status": "ok"})
+}
+
+func (ts *todoServer) removeAlias(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	a, err := aliasRead(ts.path)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, ok := a.a[name]; !ok {
+		http.Error(w, "alias not found: "+name, http.StatusNotFound)
+		return
+	}
+
+	if err := aliasRemove(ts.path, []string{name}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
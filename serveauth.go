@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// configServeToken is accepted as "Authorization: Bearer <token>".
+	configServeToken = "serve_token"
+
+	// configServePassword is accepted as HTTP basic auth, which browsers
+	// prompt for.
+	configServePassword = "serve_password"
+)
+
+// serveAuth is what serve requires of requests for a journal.
+type serveAuth struct {
+	token    string // required as a bearer token, if set
+	password string // required as basic auth, if set
+	realm    string // distinguishes journals' passwords in a browser
+}
+
+func (a *serveAuth) required() bool {
+	return a.token != "" || a.password != ""
+}
+
+// authenticate requires the token or password, if either is set.
+func (a *serveAuth) authenticate(next http.Handler) http.Handler {
+	if !a.required() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" {
+			if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secretEqual(v, a.token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if a.password != "" {
+			if _, v, ok := r.BasicAuth(); ok && secretEqual(v, a.password) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm))
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// secretEqual compares a and b in constant time, so that how long it takes
+// doesn't reveal how much of a guess was right.
+func secretEqual(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// protectCSRF rejects requests that change the journal if they come from
+// another site's page, as a browser sends the basic auth password along
+// with them.
+func protectCSRF(next http.Handler) http.Handler {
+	return http.NewCrossOriginProtection().Handler(next)
+}
+
+// statusRecorder remembers the status written, for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.size += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// logRequests logs each request once it has been answered.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		log.Printf("%v %v %v %v %v %v", host, r.Method, r.URL.Path, rec.status, rec.size, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// isLoopback reports whether a listen address only accepts connections from
+// this machine.
+func isLoopback(hostPort string) bool {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
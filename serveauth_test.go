@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := map[string]bool{
+		"localhost:8080":    true,
+		"127.0.0.1:8080":    true,
+		"[::1]:8080":        true,
+		":8080":             false,
+		"0.0.0.0:8080":      false,
+		"192.168.1.10:8080": false,
+		"example.com:8080":  false,
+		"localhost":         false,
+	}
+
+	for in, want := range tests {
+		if got := isLoopback(in); got != want {
+			t.Errorf("%q: got %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name  string
+		auth  serveAuth
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"bearer token", serveAuth{token: "secret"}, func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, http.StatusOK},
+		{"wrong bearer token", serveAuth{token: "secret"}, func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") }, http.StatusUnauthorized},
+		{"basic auth", serveAuth{password: "secret"}, func(r *http.Request) { r.SetBasicAuth("me", "secret") }, http.StatusOK},
+		{"wrong basic auth", serveAuth{password: "secret"}, func(r *http.Request) { r.SetBasicAuth("me", "guess") }, http.StatusUnauthorized},
+		{"basic auth for token", serveAuth{token: "secret"}, func(r *http.Request) { r.SetBasicAuth("me", "secret") }, http.StatusUnauthorized},
+		{"either", serveAuth{token: "t", password: "p"}, func(r *http.Request) { r.Header.Set("Authorization", "Bearer t") }, http.StatusOK},
+		{"loopback without credentials", serveAuth{}, func(r *http.Request) {}, http.StatusOK},
+		{"loopback missing credentials", serveAuth{password: "secret"}, func(r *http.Request) {}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/todos", nil)
+		r.RemoteAddr = "127.0.0.1:50000"
+		tt.setup(r)
+		w := httptest.NewRecorder()
+
+		tt.auth.authenticate(ok).ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.name, w.Code, tt.want)
+		}
+		if tt.auth.password != "" && w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%v: no WWW-Authenticate", tt.name)
+		}
+	}
+}
+
+func TestProtectCSRF(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{"cross-origin post", "POST", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"cross-origin post by origin", "POST", map[string]string{"Origin": "https://example.com"}, http.StatusForbidden},
+		{"same-origin post", "POST", map[string]string{"Sec-Fetch-Site": "same-origin"}, http.StatusOK},
+		{"post from curl", "POST", nil, http.StatusOK},
+		{"cross-origin get", "GET", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "http://localhost:8080/api/todos", nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+
+		protectCSRF(ok).ServeHTTP(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.name, w.Code, tt.want)
+		}
+	}
+}
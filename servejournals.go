@@ -0,0 +1,114 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// journalsServer serves several journals, each under /j/<journal>/.
+type journalsServer struct {
+	auth     serveAuth // for the list of journals
+	journals map[string]http.Handler
+	names    []string
+	servers  []*todoServer
+}
+
+func (js *journalsServer) serveList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	journalsTemplate.Execute(w, js.names)
+}
+
+func (js *journalsServer) getJournals(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, js.names)
+}
+
+// serveJournal passes a request to the journal its path names. Journals may
+// be nested, so the longest name matching wins.
+func (js *journalsServer) serveJournal(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/j/")
+
+	var name string
+	for _, n := range js.names {
+		if (rest == n || strings.HasPrefix(rest, n+"/")) && len(n) > len(name) {
+			name = n
+		}
+	}
+	if name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the page uses relative links, so it must be a directory
+	if rest == name {
+		http.Redirect(w, r, "/j/"+name+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	js.journals[name].ServeHTTP(w, r)
+}
+
+var journalsTemplate = template.Must(template.New("journals").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>Journals</title>
+	<style>
+		body {
+			font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
+			background-color: #f5f5f5;
+			margin: 0;
+			padding: 20px;
+		}
+
+		.container {
+			max-width: 600px;
+			margin: 0 auto;
+			background: white;
+			border-radius: 8px;
+			box-shadow: 0 2px 4px rgba(0, 0, 0, 0.1);
+			padding: 20px;
+		}
+
+		h1 {
+			color: #333;
+			margin: 0 0 20px;
+			font-size: 24px;
+		}
+
+		ul {
+			list-style: none;
+			padding: 0;
+			margin: 0;
+		}
+
+		li a {
+			display: block;
+			padding: 12px;
+			border-bottom: 1px solid #eee;
+			color: #333;
+			text-decoration: none;
+		}
+
+		li:last-child a {
+			border-bottom: none;
+		}
+
+		li a:hover {
+			background-color: #f0f0f0;
+		}
+	</style>
+</head>
+<body>
+	<div class="container">
+		<h1>Journals</h1>
+		<ul>
+		{{- range .}}
+			<li><a href="/j/{{.}}/">{{.}}</a></li>
+		{{- end}}
+		</ul>
+	</div>
+</body>
+</html>
+`))
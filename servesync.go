@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// configServeSync is how often serve pulls and pushes a journal that
+	// uses git. Requests read what's on disk rather than pulling first, and
+	// changes are pushed by the background sync rather than the request.
+	configServeSync = "serve_sync"
+
+	// shutdownTimeout is how long requests in progress have to finish once
+	// serve is asked to stop.
+	shutdownTimeout = 10 * time.Second
+)
+
+// syncStatus is the outcome of serve's last sync of a journal, as served by
+// /api/status.
+type syncStatus struct {
+	Journal  string    `json:"journal"`
+	Git      bool      `json:"git"`
+	Interval string    `json:"interval,omitempty"` // empty if only synced when asked
+	Last     time.Time `json:"last_sync,omitzero"`
+	Error    string    `json:"last_error,omitempty"`
+	Next     time.Time `json:"next_sync,omitzero"`
+}
+
+// serveSync is a journal's background sync, and its state.
+type serveSync struct {
+	git      bool
+	interval time.Duration
+	last     atomic.Pointer[syncStatus]
+	push     chan struct{} // changes made by requests, to push soon
+	stop     chan struct{} // closed when the server shuts down
+	stopped  chan struct{} // closed when the loop has returned
+}
+
+func newServeSync(c *config) *serveSync {
+	s := &serveSync{
+		git:      c.git,
+		interval: c.serveSync,
+		push:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	s.last.Store(&syncStatus{})
+	return s
+}
+
+// status returns the journal's sync status.
+func (ts *todoServer) status() *syncStatus {
+	s := *ts.sync.last.Load()
+	s.Journal = ts.name
+	s.Git = ts.sync.git
+	if ts.sync.git && ts.sync.interval > 0 {
+		s.Interval = ts.sync.interval.String()
+	}
+	return &s
+}
+
+func (ts *todoServer) recordSync(err error) {
+	s := &syncStatus{Last: time.Now()}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if ts.sync.git && ts.sync.interval > 0 {
+		s.Next = s.Last.Add(ts.sync.interval)
+	}
+	ts.sync.last.Store(s)
+}
+
+// syncNow pulls and then pushes the journal, holding its lock so as not to
+// race the server's own changes, and records the outcome.
+func (ts *todoServer) syncNow() error {
+	unlock, err := lockJournal(ts.path)
+	if err != nil {
+		return err
+	}
+
+	head := gitHead(ts.path)
+	err = doGitPull(ts.path)
+	if err == nil && gitHead(ts.path) != head {
+		if err := indexPulled(ts.path, head); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if err == nil {
+		err = doGitPush(ts.path)
+	}
+	unlock()
+
+	ts.recordSync(err)
+	return err
+}
+
+// startSync syncs the journal in the background, if it uses git, until
+// stopSync: on a schedule, and as soon as a request changes it. Meanwhile
+// requests don't pull first or push themselves.
+func (ts *todoServer) startSync() {
+	if !ts.sync.git || ts.sync.interval == 0 {
+		close(ts.sync.stopped)
+		return
+	}
+
+	ts.sync.last.Store(&syncStatus{Next: time.Now().Add(ts.sync.interval)})
+	setBackgroundSync(ts.path, ts.sync.push)
+	go func() {
+		defer close(ts.sync.stopped)
+		defer setBackgroundSync(ts.path, nil)
+		t := time.NewTicker(ts.sync.interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ts.sync.stop:
+				return
+			case <-t.C:
+			case <-ts.sync.push:
+				t.Reset(ts.sync.interval)
+			}
+			if err := ts.syncNow(); err != nil {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", ts.name, err)
+			}
+		}
+	}()
+}
+
+// stopSync stops the background sync and event streams, without waiting.
+func (ts *todoServer) stopSync() {
+	close(ts.sync.stop)
+}
+
+// finishSync waits for the background sync to stop, and then pushes
+// anything not yet pushed, such as changes made while the remote was
+// unreachable.
+func (ts *todoServer) finishSync() {
+	<-ts.sync.stopped
+	if !ts.sync.git {
+		return
+	}
+
+	fmt.Printf("Pushing %v\n", ts.name)
+	unlock, err := lockJournal(ts.path)
+	if err == nil {
+		err = doGitPush(ts.path)
+		unlock()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", ts.name, err)
+	}
+}
+
+func (ts *todoServer) getStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ts.status())
+}
+
+func (js *journalsServer) getStatus(w http.ResponseWriter, r *http.Request) {
+	var ret []*syncStatus
+	for _, ts := range js.servers {
+		ret = append(ret, ts.status())
+	}
+	writeJSON(w, http.StatusOK, ret)
+}
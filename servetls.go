@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	configServeTLS  = "serve_tls"
+	configServeCert = "serve_cert"
+	configServeKey  = "serve_key"
+
+	// the self-signed certificate, kept with the user config rather than
+	// in a journal so that its key isn't synced
+	serveCertFile = "serve-cert.pem"
+	serveKeyFile  = "serve-key.pem"
+)
+
+// serveTLSConfig returns the TLS config serve should use, or nil to serve
+// plain HTTP.
+func serveTLSConfig(c *config, hostPort string) (*tls.Config, error) {
+	if c.serveCert != "" || c.serveKey != "" {
+		if c.serveCert == "" || c.serveKey == "" {
+			return nil, fmt.Errorf("%v and %v must both be set", configServeCert, configServeKey)
+		}
+		certFile, err := expandHome(c.serveCert)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := expandHome(c.serveKey)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+	}
+
+	if !c.serveTLS {
+		return nil, nil
+	}
+
+	cert, err := selfSignedCert(hostPort)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Using a self-signed certificate with SHA-256 fingerprint %v\n", fingerprint(cert.Leaf))
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+// selfSignedCert returns a certificate for serving on hostPort, reusing the
+// one saved with the user config if it is still valid for it, so that a
+// browser's exception for it keeps working.
+func selfSignedCert(hostPort string) (tls.Certificate, error) {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	var certFile, keyFile string
+	if userConfigPath != "" {
+		dir := filepath.Dir(userConfigPath)
+		certFile = filepath.Join(dir, serveCertFile)
+		keyFile = filepath.Join(dir, serveKeyFile)
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err == nil && certCovers(cert.Leaf, host, time.Now()) {
+			return cert, nil
+		}
+	}
+
+	certPEM, keyPEM, err := newSelfSignedCert(host, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if certFile != "" {
+		err = os.MkdirAll(filepath.Dir(certFile), 0700)
+		if err == nil {
+			err = os.WriteFile(keyFile, keyPEM, 0600)
+		}
+		if err == nil {
+			err = os.WriteFile(certFile, certPEM, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: not saving the self-signed certificate: %v\n", err)
+		}
+	}
+
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// certCovers reports whether cert is valid for host for at least another
+// day. An empty host, listening everywhere, needs only localhost.
+func certCovers(cert *x509.Certificate, host string, now time.Time) bool {
+	if cert == nil || now.Before(cert.NotBefore) || now.Add(24*time.Hour).After(cert.NotAfter) {
+		return false
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return cert.VerifyHostname(host) == nil
+}
+
+// newSelfSignedCert creates a certificate and key, PEM encoded, valid for a
+// year for host, localhost and this machine's name.
+func newSelfSignedCert(host string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "tb serve"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if name, err := os.Hostname(); err == nil && name != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, name)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsLoopback() && !ip.IsUnspecified() {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		}
+	} else if host != "" && host != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// fingerprint formats the SHA-256 hash of a certificate as browsers show it.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	var parts []string
+	for _, b := range sum {
+		parts = append(parts, fmt.Sprintf("%02X", b))
+	}
+	return strings.Join(parts, ":")
+}
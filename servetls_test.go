@@ -0,0 +1,31 @@
+package main
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+)
+
+func TestSelfSignedCert(t *testing.T) {
+	now := time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)
+	certPEM, keyPEM, err := newSelfSignedCert("journal.example", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, host := range []string{"", "localhost", "127.0.0.1", "::1", "journal.example"} {
+		if !certCovers(cert.Leaf, host, now) {
+			t.Errorf("%q: not covered", host)
+		}
+	}
+	if certCovers(cert.Leaf, "other.example", now) {
+		t.Errorf("other.example: covered")
+	}
+	if certCovers(cert.Leaf, "localhost", now.AddDate(1, 0, 0)) {
+		t.Errorf("expired certificate: covered")
+	}
+}
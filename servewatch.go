@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// watchDelay gathers the changes of one command, such as a git pull,
+	// into one batch of events.
+	watchDelay = 250 * time.Millisecond
+
+	// watchBatch is the most events sent for one batch. Beyond it, a
+	// single journal event says to reload everything.
+	watchBatch = 20
+
+	// eventPing keeps idle event streams open through proxies.
+	eventPing = 30 * time.Second
+)
+
+// journalEvent is a change to a journal, as sent to the web UI.
+type journalEvent struct {
+	Type string `json:"type"`           // day, todos, aliases or journal
+	Date string `json:"date,omitempty"` // the day changed
+}
+
+// journalChange returns the event for a change to the file name in the
+// journal at path, if it is one the web UI shows.
+func journalChange(path, name string) (journalEvent, bool) {
+	rel, err := filepath.Rel(path, name)
+	if err != nil {
+		return journalEvent{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	// temporary files and .git
+	for _, p := range parts {
+		if strings.HasPrefix(p, ".") && rel != tagebuchMagic {
+			return journalEvent{}, false
+		}
+	}
+
+	switch {
+	case len(parts) >= 3:
+		t, err := parseYMD(parts[:3])
+		if err != nil {
+			return journalEvent{}, false
+		}
+		return journalEvent{Type: "day", Date: dateString(t)}, true
+	case rel == tagebuchTodo || rel == tagebuchDone:
+		return journalEvent{Type: "todos"}, true
+	case rel == tagebuchAliases:
+		return journalEvent{Type: "aliases"}, true
+	case rel == tagebuchMagic:
+		return journalEvent{Type: "journal"}, true
+	}
+	return journalEvent{}, false
+}
+
+// eventHub passes journal events to every subscribed event stream.
+type eventHub struct {
+	subscribe   chan chan journalEvent
+	unsubscribe chan chan journalEvent
+	publish     chan journalEvent
+}
+
+func newEventHub() *eventHub {
+	h := &eventHub{
+		subscribe:   make(chan chan journalEvent),
+		unsubscribe: make(chan chan journalEvent),
+		publish:     make(chan journalEvent),
+	}
+	go h.run()
+	return h
+}
+
+func (h *eventHub) run() {
+	subs := make(map[chan journalEvent]bool)
+	for {
+		select {
+		case c := <-h.subscribe:
+			subs[c] = true
+		case c := <-h.unsubscribe:
+			delete(subs, c)
+		case e := <-h.publish:
+			for c := range subs {
+				// a stream too slow to keep up misses events rather
+				// than holding up the others
+				select {
+				case c <- e:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// watchJournal publishes changes to the journal at path to h, as they are
+// made by any process.
+func watchJournal(path string, h *eventHub) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// fsnotify doesn't watch subdirectories, so each is added, and any
+	// created later as they appear. A journal can have more days than the
+	// system allows watches, so the newest days are watched first, and once
+	// a watch fails the rest are left unwatched with a single warning.
+	// Months are still watched, so new days show up regardless.
+	warned := false
+	addDirs := func(root string) {
+		dirs, days := watchDirs(path, root)
+		for _, p := range dirs {
+			if err := w.Add(p); err != nil {
+				fmt.Fprintf(os.Stderr, "watch %v: %v\n", p, err)
+			}
+		}
+		for _, p := range days {
+			if err := w.Add(p); err != nil {
+				if !warned {
+					fmt.Fprintf(os.Stderr, "warning: not watching %v or older days for changes: %v\n", p, err)
+					warned = true
+				}
+				return
+			}
+		}
+	}
+	addDirs(path)
+
+	go func() {
+		var pending []journalEvent
+		timer := time.NewTimer(watchDelay)
+		timer.Stop()
+
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Has(fsnotify.Create) {
+					if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+						addDirs(ev.Name)
+					}
+				}
+				e, ok := journalChange(path, ev.Name)
+				if !ok {
+					continue
+				}
+				if len(pending) == 0 {
+					timer.Reset(watchDelay)
+				}
+				if !slices.Contains(pending, e) {
+					pending = append(pending, e)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "watch %v: %v\n", path, err)
+			case <-timer.C:
+				if len(pending) > watchBatch {
+					pending = []journalEvent{{Type: "journal"}}
+				}
+				for _, e := range pending {
+					h.publish <- e
+				}
+				pending = nil
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchDirs returns the directories under root, within the journal at path,
+// to watch: day directories, newest first, separately from the rest.
+func watchDirs(path, root string) ([]string, []string) {
+	var dirs, days []string
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if p != path && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if e, ok := journalChange(path, p); ok && e.Type == "day" {
+			days = append(days, p)
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, p)
+		return nil
+	})
+
+	date := func(p string) string {
+		e, _ := journalChange(path, p)
+		return e.Date
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return compareDates(date(days[j]), date(days[i]))
+	})
+
+	return dirs, days
+}
+
+// streamEvents sends the journal's changes as server-sent events until the
+// client goes away.
+func (ts *todoServer) streamEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	c := make(chan journalEvent, watchBatch)
+	ts.events.subscribe <- c
+	defer func() { ts.events.unsubscribe <- c }()
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(eventPing)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ts.sync.stop:
+			// the server is shutting down
+			return
+		case e := <-c:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
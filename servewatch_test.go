@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestJournalChange(t *testing.T) {
+	path := filepath.FromSlash("/home/me/.tb/work")
+	tests := map[string]journalEvent{
+		"2026/1/6/entry":          {Type: "day", Date: "2026/1/6"},
+		"2026/01/06/photo.jpg":    {Type: "day", Date: "2026/1/6"},
+		"todo":                    {Type: "todos"},
+		"done":                    {Type: "todos"},
+		"aliases":                 {Type: "aliases"},
+		".tagebuch":               {Type: "journal"},
+		"2026/1/6/.entry.tmp1234": {},
+		".git/index":              {},
+		"index":                   {},
+		"templates/daily":         {},
+		"2026/1":                  {},
+		"2026/2/30/entry":         {},
+	}
+
+	for in, want := range tests {
+		got, ok := journalChange(path, filepath.Join(path, filepath.FromSlash(in)))
+		if ok != (want.Type != "") || got != want {
+			t.Errorf("%v: got %+v %v, want %+v", in, got, ok, want)
+		}
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	path := t.TempDir()
+	for _, d := range []string{"2025/12/31", "2026/1/6", "2026/1/10", "2026/2/1", "templates", ".git/objects"} {
+		if err := os.MkdirAll(filepath.Join(path, filepath.FromSlash(d)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rel := func(ps []string) []string {
+		var ret []string
+		for _, p := range ps {
+			r, _ := filepath.Rel(path, p)
+			ret = append(ret, filepath.ToSlash(r))
+		}
+		return ret
+	}
+
+	dirs, days := watchDirs(path, path)
+	if got, want := rel(dirs), []string{".", "2025", "2025/12", "2026", "2026/1", "2026/2", "templates"}; !slices.Equal(got, want) {
+		t.Errorf("dirs: got %v, want %v", got, want)
+	}
+	if got, want := rel(days), []string{"2026/2/1", "2026/1/10", "2026/1/6", "2025/12/31"}; !slices.Equal(got, want) {
+		t.Errorf("days: got %v, want %v", got, want)
+	}
+
+	// a new month, as created while watching
+	dirs, days = watchDirs(path, filepath.Join(path, "2026", "2"))
+	if got, want := rel(dirs), []string{"2026/2"}; !slices.Equal(got, want) {
+		t.Errorf("dirs: got %v, want %v", got, want)
+	}
+	if got, want := rel(days), []string{"2026/2/1"}; !slices.Equal(got, want) {
+		t.Errorf("days: got %v, want %v", got, want)
+	}
+}
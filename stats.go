@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// journalStats summarizes a journal for stats, and is its JSON output.
+type journalStats struct {
+	Entries       int            `json:"entries"`
+	First         string         `json:"first,omitempty"`
+	Last          string         `json:"last,omitempty"`
+	Words         int            `json:"words"`
+	WordsPerEntry float64        `json:"words_per_entry"`
+	Months        []monthStats   `json:"months"`
+	LongestStreak streak         `json:"longest_streak"`
+	CurrentStreak streak         `json:"current_streak"`
+	Weekdays      []weekdayStats `json:"weekdays"`
+	Files         int            `json:"files"`
+	FileBytes     int64          `json:"file_bytes"`
+	Todos         todoStats      `json:"todos"`
+}
+
+type monthStats struct {
+	Month         string  `json:"month"` // year/month
+	Entries       int     `json:"entries"`
+	Words         int     `json:"words"`
+	WordsPerEntry float64 `json:"words_per_entry"`
+}
+
+// streak is a run of consecutive days with entries.
+type streak struct {
+	Days  int    `json:"days"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type weekdayStats struct {
+	Weekday string `json:"weekday"`
+	Entries int    `json:"entries"`
+}
+
+type todoStats struct {
+	Open            int     `json:"open"`
+	Completed       int     `json:"completed"`
+	CompletedWeek   int     `json:"completed_last_7_days"`
+	CompletedMonth  int     `json:"completed_last_30_days"`
+	CreatedMonth    int     `json:"created_last_30_days"`
+	CompletedPerDay float64 `json:"completed_per_day"` // over the last 30 days
+}
+
+// statDay is what stats needs to know about a day with an entry or attached
+// files. Days with only files count towards files, not entries.
+type statDay struct {
+	t         time.Time
+	entry     bool
+	words     int
+	files     int
+	fileBytes int64
+}
+
+func stats(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fJSON := fs.Bool("json", false, "print as JSON")
+	fRange := fs.String("r", "", "only count entries in a date range")
+
+	err = fs.Parse(x)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("trailing commands: %v", fs.Args())
+	}
+
+	var start, end time.Time
+	if *fRange != "" {
+		var rest []string
+		start, end, rest, err = parseRangeArg(path, strings.Fields(*fRange))
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("invalid range: %v", *fRange)
+		}
+	}
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var days []statDay
+	for _, e := range dayDates(path) {
+		t, err := parseDate(e, now)
+		if err != nil {
+			continue
+		}
+		if !start.IsZero() && (t.Before(start) || t.After(end)) {
+			continue
+		}
+
+		text, err := entryText(path, e)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		d := statDay{t: t, entry: len(text) > 0, words: len(strings.Fields(text))}
+
+		files, err := listFilesInDay(dayPath(path, t))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			info, err := os.Stat(filepath.Join(dayPath(path, t), f))
+			if err != nil {
+				return err
+			}
+			d.files++
+			d.fileBytes += info.Size()
+		}
+		days = append(days, d)
+	}
+
+	s := computeStats(days, today)
+
+	t, err := todoRead(path, tagebuchTodo)
+	if err == nil {
+		s.Todos.Open = len(t.t)
+		for _, v := range t.t {
+			if !v.created.IsZero() && today.Sub(v.created) < 30*24*time.Hour {
+				s.Todos.CreatedMonth++
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	d, err := doneLoad(path)
+	if err != nil {
+		return err
+	}
+	s.Todos.Completed = len(d.t)
+	for _, v := range d.t {
+		age := today.Sub(v.completed)
+		if age < 7*24*time.Hour {
+			s.Todos.CompletedWeek++
+		}
+		if age < 30*24*time.Hour {
+			s.Todos.CompletedMonth++
+		}
+	}
+	s.Todos.CompletedPerDay = float64(s.Todos.CompletedMonth) / 30
+
+	if *fJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "\t")
+		return enc.Encode(s)
+	}
+
+	s.print()
+	return nil
+}
+
+// computeStats summarizes days, which must be in order. The current streak
+// is still going if the last entry was yesterday, as today may yet be
+// written.
+func computeStats(days []statDay, today time.Time) *journalStats {
+	s := &journalStats{
+		Months:   []monthStats{},
+		Weekdays: []weekdayStats{},
+	}
+
+	weekdays := make([]int, 7)
+	var run streak
+	var prev time.Time
+	for _, d := range days {
+		s.Files += d.files
+		s.FileBytes += d.fileBytes
+		if !d.entry {
+			continue
+		}
+		s.Entries++
+		if s.Entries == 1 {
+			s.First = dateString(d.t)
+		}
+		s.Words += d.words
+		weekdays[d.t.Weekday()]++
+
+		month := fmt.Sprintf("%d/%d", d.t.Year(), int(d.t.Month()))
+		if n := len(s.Months); n == 0 || s.Months[n-1].Month != month {
+			s.Months = append(s.Months, monthStats{Month: month})
+		}
+		m := &s.Months[len(s.Months)-1]
+		m.Entries++
+		m.Words += d.words
+
+		date := dateString(d.t)
+		if !prev.IsZero() && d.t.Equal(prev.AddDate(0, 0, 1)) {
+			run.Days++
+			run.End = date
+		} else {
+			run = streak{Days: 1, Start: date, End: date}
+		}
+		if run.Days > s.LongestStreak.Days {
+			s.LongestStreak = run
+		}
+		prev = d.t
+	}
+
+	if s.Entries == 0 {
+		return s
+	}
+
+	s.Last = dateString(prev)
+	s.WordsPerEntry = float64(s.Words) / float64(s.Entries)
+	for i := range s.Months {
+		s.Months[i].WordsPerEntry = float64(s.Months[i].Words) / float64(s.Months[i].Entries)
+	}
+	if prev.Equal(today) || prev.Equal(today.AddDate(0, 0, -1)) {
+		s.CurrentStreak = run
+	}
+
+	for i, n := range weekdays {
+		if n > 0 {
+			s.Weekdays = append(s.Weekdays, weekdayStats{Weekday: time.Weekday(i).String(), Entries: n})
+		}
+	}
+	sort.SliceStable(s.Weekdays, func(i, j int) bool { return s.Weekdays[i].Entries > s.Weekdays[j].Entries })
+
+	return s
+}
+
+func (s *journalStats) print() {
+	if s.Entries == 0 {
+		fmt.Println("entries:        0")
+	} else {
+		fmt.Printf("entries:        %v (%v to %v)\n", s.Entries, s.First, s.Last)
+		fmt.Printf("words:          %v (%.0f per entry)\n", s.Words, s.WordsPerEntry)
+		fmt.Printf("longest streak: %v\n", s.LongestStreak)
+		fmt.Printf("current streak: %v\n", s.CurrentStreak)
+
+		var busiest []string
+		for _, v := range s.Weekdays {
+			busiest = append(busiest, fmt.Sprintf("%v %v", v.Weekday, v.Entries))
+		}
+		fmt.Printf("busiest days:   %v\n", strings.Join(busiest, ", "))
+	}
+	fmt.Printf("files:          %v (%v)\n", s.Files, humanSize(s.FileBytes))
+	fmt.Printf("todos:          %v open, %v completed (%v in the last 7 days, %v in the last 30), %v added in the last 30 days\n",
+		s.Todos.Open, s.Todos.Completed, s.Todos.CompletedWeek, s.Todos.CompletedMonth, s.Todos.CreatedMonth)
+
+	if len(s.Months) > 0 {
+		fmt.Println("months:")
+		for _, m := range s.Months {
+			fmt.Printf("  %-8v %4v entries %7v words (%.0f per entry)\n", m.Month, m.Entries, m.Words, m.WordsPerEntry)
+		}
+	}
+}
+
+func (s streak) String() string {
+	switch s.Days {
+	case 0:
+		return "none"
+	case 1:
+		return fmt.Sprintf("1 day (%v)", s.Start)
+	}
+	return fmt.Sprintf("%v days (%v to %v)", s.Days, s.Start, s.End)
+}
+
+// humanSize formats a number of bytes, e.g. 1.5 MB.
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	day := func(m, d, words int) statDay {
+		return statDay{t: time.Date(2026, time.Month(m), d, 0, 0, 0, 0, time.Local), entry: true, words: words}
+	}
+	files := statDay{t: time.Date(2026, 1, 29, 0, 0, 0, 0, time.Local), files: 2, fileBytes: 100}
+	days := []statDay{
+		files,
+		day(1, 30, 10),
+		day(1, 31, 20),
+		day(2, 1, 30),
+		day(2, 5, 40),
+		day(2, 6, 0),
+	}
+	today := time.Date(2026, 2, 7, 0, 0, 0, 0, time.Local)
+
+	s := computeStats(days, today)
+
+	if s.Entries != 5 || s.Words != 100 || s.WordsPerEntry != 20 {
+		t.Errorf("got %v entries, %v words, %v per entry", s.Entries, s.Words, s.WordsPerEntry)
+	}
+	if s.First != "2026/1/30" || s.Files != 2 || s.FileBytes != 100 {
+		t.Errorf("got first %v, %v files of %v bytes", s.First, s.Files, s.FileBytes)
+	}
+	if s.LongestStreak != (streak{Days: 3, Start: "2026/1/30", End: "2026/2/1"}) {
+		t.Errorf("longest streak: %+v", s.LongestStreak)
+	}
+	if s.CurrentStreak != (streak{Days: 2, Start: "2026/2/5", End: "2026/2/6"}) {
+		t.Errorf("current streak: %+v", s.CurrentStreak)
+	}
+	if len(s.Months) != 2 || s.Months[1].Month != "2026/2" || s.Months[1].Entries != 3 || s.Months[1].Words != 70 {
+		t.Errorf("months: %+v", s.Months)
+	}
+
+	// a streak ending before yesterday is over
+	s = computeStats(days, today.AddDate(0, 0, 2))
+	if s.CurrentStreak.Days != 0 {
+		t.Errorf("current streak: %+v", s.CurrentStreak)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1536:    "1.5 KB",
+		5 << 20: "5.0 MB",
+	}
+	for n, want := range tests {
+		if got := humanSize(n); got != want {
+			t.Errorf("humanSize(%v): got %v, want %v", n, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	gosync "sync" // sync is the sync command
 )
 
 func tagebuch(x []string) error {
@@ -12,30 +13,74 @@ func tagebuch(x []string) error {
 	}
 
 	p := filepath.Join(baseDir, x[0])
+	if isJournal(p) {
+		return base(p, x[1:])
+	}
+
+	if x[0] == "config" {
+		return userConfigCommand(x[1:])
+	}
+
+	// serve without a journal serves them all
+	if x[0] == "serve" {
+		return serveAll(x[1:])
+	}
+
+	// a command without a journal uses the default journal
+	if userConfig != nil && userConfig.defaultJournal != "" {
+		if _, err := baseCommand(x[0]); err == nil {
+			return base(filepath.Join(baseDir, userConfig.defaultJournal), x)
+		}
+	}
 
 	return base(p, x[1:])
 }
 
-func listJournals() error {
-	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
-		fmt.Println("no journals found")
-		return nil
+func isJournal(path string) bool {
+	_, err := os.Stat(filepath.Join(path, tagebuchMagic))
+	return err == nil
+}
+
+// journalRoot returns the journal containing path, which may be a directory
+// within it such as a year, or path itself if it isn't in a journal.
+func journalRoot(path string) string {
+	for p := path; ; {
+		if isJournal(p) {
+			return p
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return path
+		}
+		p = parent
 	}
+}
 
+// findJournals returns the names of the journals in baseDir.
+func findJournals() []string {
+	return journalsIn(baseDir)
+}
+
+// journalsIn returns the journals under dir, relative to it.
+func journalsIn(dir string) []string {
 	var journals []string
-	filepath.WalkDir(baseDir, func(p string, d os.DirEntry, err error) error {
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
 		if d.Name() == tagebuchMagic && !d.IsDir() {
-			rel, err := filepath.Rel(baseDir, filepath.Dir(p))
+			rel, err := filepath.Rel(dir, filepath.Dir(p))
 			if err == nil {
 				journals = append(journals, rel)
 			}
 		}
 		return nil
 	})
+	return journals
+}
 
+func listJournals() error {
+	journals := findJournals()
 	if len(journals) == 0 {
 		fmt.Println("no journals found")
 		return nil
@@ -43,15 +88,33 @@ func listJournals() error {
 
 	fmt.Println("available journals:")
 	for _, j := range journals {
+		if userConfig != nil && j == userConfig.defaultJournal {
+			j += " (default)"
+		}
 		fmt.Println("  " + j)
 	}
 	return nil
 }
 
+// configWarned holds the journals already warned about unknown config keys,
+// as validate is called more than once by some commands, and for every
+// request by serve.
+var configWarned gosync.Map
+
 func validate(path string) error {
 	_, err := os.Stat(filepath.Join(path, tagebuchMagic))
 	if err != nil {
 		return fmt.Errorf("invalid tagebuch: %v: %v", path, err)
 	}
+
+	c, err := getConfig(path)
+	if err != nil {
+		return err
+	}
+	if _, warned := configWarned.LoadOrStore(path, true); !warned {
+		for _, k := range c.unknown {
+			fmt.Fprintf(os.Stderr, "warning: unknown config key: %v\n", k)
+		}
+	}
 	return nil
 }
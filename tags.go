@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// reTag matches #tags and @mentions in entry text. They must start a word,
+// so markdown headings, email addresses and URL fragments aren't tags.
+var reTag = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#@])([#@][\p{L}\p{N}_](?:[\p{L}\p{N}_-]*[\p{L}\p{N}_])?)`)
+
+// extractTags returns the tags and mentions in text, lowercased, once each
+// in order of appearance.
+func extractTags(text string) []string {
+	var ret []string
+	for _, m := range reTag.FindAllStringSubmatch(text, -1) {
+		t := strings.ToLower(m[1])
+		if !slices.Contains(ret, t) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// matchTag reports whether tag, as returned by extractTags, is the one
+// named. A name without # or @ matches either.
+func matchTag(tag, name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "#") || strings.HasPrefix(name, "@") {
+		return tag == name
+	}
+	return tag[1:] == name
+}
+
+// entryText returns a day's entry, decrypted.
+func entryText(path, date string) (string, error) {
+	data, err := readJournalFile(path, filepath.Join(path, date, entryName))
+	return string(data), err
+}
+
+// taggedDates returns the dates of entries with the named tag or mention.
+func taggedDates(path, name string) ([]string, error) {
+	var ret []string
+	for _, e := range entryDates(path) {
+		text, err := entryText(path, e)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range extractTags(text) {
+			if matchTag(t, name) {
+				ret = append(ret, e)
+				break
+			}
+		}
+	}
+	return ret, nil
+}
+
+// tags lists every tag and mention with the number of entries using it.
+func tags(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	if len(x) != 0 {
+		return fmt.Errorf("trailing commands: %v", x)
+	}
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	counts := make(map[string]int)
+	for _, e := range entryDates(path) {
+		text, err := entryText(path, e)
+		if err != nil {
+			return err
+		}
+		for _, t := range extractTags(text) {
+			counts[t]++
+		}
+	}
+
+	var names []string
+	for k := range counts {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	for _, k := range names {
+		fmt.Printf("%v %v\n", counts[k], k)
+	}
+	return nil
+}
+
+// tag lists the entries with a tag or mention, each with the first line
+// using it.
+func tag(path string, x []string) error {
+	err := validate(path)
+	if err != nil {
+		return err
+	}
+
+	if len(x) == 0 {
+		return fmt.Errorf("must provide tag, e.g. release, #release or @alice")
+	}
+	if len(x) != 1 {
+		return fmt.Errorf("trailing commands: %v", x[1:])
+	}
+	name := x[0]
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	dates, err := taggedDates(path, name)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range dates {
+		text, err := entryText(path, e)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if lineHasTag(line, name) {
+				fmt.Printf("%v: %v\n", e, strings.TrimSpace(line))
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func lineHasTag(line, name string) bool {
+	for _, t := range extractTags(line) {
+		if matchTag(t, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	text := `## Heading
+shipped #release with @Alice and #Release again, #v2-beta.
+mail bob@example.com or see http://example.com/#frag &#43;
+#start`
+
+	got := extractTags(text)
+	want := []string{"#release", "@alice", "#v2-beta", "#start"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMatchTag(t *testing.T) {
+	tests := []struct {
+		tag, name string
+		want      bool
+	}{
+		{"#release", "release", true},
+		{"#release", "#Release", true},
+		{"#release", "@release", false},
+		{"@alice", "alice", true},
+		{"@alice", "@alice", true},
+		{"#release", "rel", false},
+	}
+	for _, v := range tests {
+		if got := matchTag(v.tag, v.name); got != v.want {
+			t.Errorf("matchTag(%q, %q): got %v", v.tag, v.name, got)
+		}
+	}
+}
@@ -17,22 +17,56 @@ var (
 func main() {
 	flag.Parse()
 
-	if strings.HasPrefix(*fBase, "~") {
-		hd, err := os.UserHomeDir()
+	err := loadSettings()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = tagebuch(flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// loadSettings reads the user config and sets the journal directory from
+// -b, or the user config if -b wasn't given.
+func loadSettings() error {
+	dir, err := os.UserConfigDir()
+	if err == nil {
+		userConfigPath = filepath.Join(dir, "tb", "config")
+		userConfig, err = loadUserConfig(userConfigPath)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return err
+		}
+		for _, k := range userConfig.unknown {
+			fmt.Fprintf(os.Stderr, "warning: unknown config key: %v\n", k)
 		}
-		baseDir = filepath.Join(hd, strings.TrimPrefix(*fBase, "~"))
-	} else {
-		baseDir = *fBase
 	}
 
-	args := os.Args[1:]
+	b := *fBase
+	explicit := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "b" {
+			explicit = true
+		}
+	})
+	if !explicit && userConfig != nil && userConfig.base != "" {
+		b = userConfig.base
+	}
 
-	err := tagebuch(args)
+	baseDir, err = expandHome(b)
+	return err
+}
+
+func expandHome(p string) (string, error) {
+	if !strings.HasPrefix(p, "~") {
+		return p, nil
+	}
+	hd, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return "", err
 	}
+	return filepath.Join(hd, strings.TrimPrefix(p, "~")), nil
 }
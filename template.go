@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	tagebuchTemplates = "templates"
+
+	// configTemplate names the template used for new entries when none is
+	// given.
+	configTemplate = "template"
+)
+
+// templateData is what an entry template is rendered with.
+type templateData struct {
+	Date         time.Time
+	Weekday      string
+	Todos        []templateTodo
+	Previous     string    // the last entry before this day, usually yesterday's
+	PreviousDate time.Time // zero if there is no earlier entry
+}
+
+type templateTodo struct {
+	ID   string
+	Text string
+}
+
+var templateFuncs = template.FuncMap{
+	"section": section,
+}
+
+var reHeading = regexp.MustCompile(`^(#+)\s+(.*?)\s*$`)
+
+// section returns the lines of the markdown section titled title in text, up
+// to the next heading of the same or a higher level. The title is matched
+// without regard to case.
+func section(title, text string) string {
+	var ret []string
+	level := 0
+	for _, line := range strings.Split(text, "\n") {
+		m := reHeading.FindStringSubmatch(line)
+		if level != 0 {
+			if m != nil && len(m[1]) <= level {
+				break
+			}
+			ret = append(ret, strings.TrimRight(line, " \t\r"))
+			continue
+		}
+		if m != nil && strings.EqualFold(m[2], title) {
+			level = len(m[1])
+		}
+	}
+
+	// drop surrounding blank lines
+	for len(ret) > 0 && ret[0] == "" {
+		ret = ret[1:]
+	}
+	for len(ret) > 0 && ret[len(ret)-1] == "" {
+		ret = ret[:len(ret)-1]
+	}
+	return strings.Join(ret, "\n")
+}
+
+// templateName returns the template to use for a new entry: the one given,
+// or the journal's template setting.
+func templateName(path, name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	c, err := getConfig(path)
+	if err != nil {
+		return "", err
+	}
+	return c.template, nil
+}
+
+// renderTemplate renders the named template for the entry on day t.
+func renderTemplate(path, name string, t time.Time) ([]byte, error) {
+	if name == "." || name == ".." || filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid template name: %v", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, tagebuchTemplates, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unknown template: %v. Templates are: %v", name, strings.Join(templateList(path), ", "))
+	} else if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &templateData{
+		Date:    t,
+		Weekday: t.Weekday().String(),
+	}
+
+	todos, err := todoRead(path, tagebuchTodo)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if todos != nil {
+		for _, v := range todos.t {
+			if !v.hidden(t) {
+				d.Todos = append(d.Todos, templateTodo{ID: v.id, Text: v.display()})
+			}
+		}
+	}
+
+	day := dateString(t)
+	var previous string
+	for _, e := range entryDates(path) {
+		if !compareDates(e, day) {
+			break
+		}
+		previous = e
+	}
+	if previous != "" {
+		p, err := readJournalFile(path, filepath.Join(path, previous, entryName))
+		if err != nil {
+			return nil, err
+		}
+		d.Previous = string(p)
+		d.PreviousDate, err = parseDate(previous, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, d)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// templateList returns the names of the journal's templates.
+func templateList(path string) []string {
+	entries, err := os.ReadDir(filepath.Join(path, tagebuchTemplates))
+	if err != nil {
+		return nil
+	}
+	var ret []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			ret = append(ret, e.Name())
+		}
+	}
+	return ret
+}
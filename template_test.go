@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestSection(t *testing.T) {
+	text := "# Monday\n\n## Tomorrow\n\n- finish report\n### details\nmore\n\n## Notes\nnothing\n"
+
+	tests := map[string]string{
+		"tomorrow": "- finish report\n### details\nmore",
+		"Notes":    "nothing",
+		"details":  "more",
+		"missing":  "",
+	}
+
+	for title, want := range tests {
+		got := section(title, text)
+		if got != want {
+			t.Errorf("section(%q): got %q, want %q", title, got, want)
+		}
+	}
+}
@@ -2,22 +2,42 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// ErrTodoNotFound is returned when an id doesn't match any item, such as
+// one that was completed elsewhere since it was listed.
+var ErrTodoNotFound = errors.New("todo not found")
+
 var todoCommands = &Options{
 	commands: []string{
 		"add",
 		"complete",
+		"list",
+		"edit",
+		"move",
+		"done",
+		"reopen",
+		"purge",
 	},
 	descriptions: []string{
-		"add a todo item",
-		"complete a todo item by number",
+		"add a todo item: todo add [(A)] <text> [+project] [@context] [due:<date>] [every <when>]",
+		"complete todo items: todo complete <id|number|range>...",
+		"list todo items: todo list [-a] [-s due|priority|created] [+project] [@context] [(A)] [due:<date>] [word]",
+		"edit a todo item: todo edit <id> <text>, or the whole list in $EDITOR: todo edit",
+		"move a todo item: todo move <id> <position>",
+		"list completed todo items: todo done [filters]",
+		"restore a completed todo item: todo reopen <id>",
+		"remove completed todo items: todo purge -before <date>",
 	},
 }
 
@@ -42,37 +62,41 @@ func todo(path string, x []string) error {
 		return todoAdd(path, x[1:])
 	case "complete":
 		return todoComplete(path, x[1:])
+	case "list":
+		return todoList(path, x[1:])
+	case "edit":
+		return todoEdit(path, x[1:])
+	case "move":
+		return todoMove(path, x[1:])
+	case "done":
+		return todoDone(path, x[1:])
+	case "reopen":
+		return todoReopen(path, x[1:])
+	case "purge":
+		return todoPurge(path, x[1:])
 	default:
 		return fmt.Errorf("invalid command %v", r)
 	}
 }
 
 type todos struct {
-	t []string
+	t    []*todoItem
+	file string // name of the file within the journal
 }
 
 func (t *todos) String() string {
 	var ret string
 	for i, v := range t.t {
-		ret += fmt.Sprintf("%v: %v\n", i, v)
+		ret += fmt.Sprintf("%v. %v: %v\n", i+1, v.id, v.display())
 	}
 	return strings.TrimSpace(ret)
 }
 
 func (t *todos) save(path string) error {
-	pt := filepath.Join(path, tagebuchTodo)
-	f, err := os.OpenFile(pt, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	err := t.write(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	for _, v := range t.t {
-		_, err = f.WriteString(v + "\n")
-		if err != nil {
-			return err
-		}
-	}
 
 	err = syncPush(path)
 	if err != nil {
@@ -82,6 +106,16 @@ func (t *todos) save(path string) error {
 	return nil
 }
 
+// write saves the list without syncing, for when several files change at
+// once.
+func (t *todos) write(path string) error {
+	var b strings.Builder
+	for _, v := range t.t {
+		b.WriteString(v.String() + "\n")
+	}
+	return writeFileAtomic(filepath.Join(path, t.file), strings.NewReader(b.String()))
+}
+
 func todoLoad(path string) (*todos, error) {
 	err := syncPull(path)
 	if err != nil {
@@ -89,20 +123,41 @@ func todoLoad(path string) (*todos, error) {
 	}
 
 	// base path is already validated
-	pt := filepath.Join(path, tagebuchTodo)
+	return todoRead(path, tagebuchTodo)
+}
+
+// todoRead reads a list in todo.txt format from the journal without syncing.
+func todoRead(path, file string) (*todos, error) {
+	pt := filepath.Join(path, file)
 	f, err := os.Open(pt)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	t := &todos{}
+	t := &todos{file: file}
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		text := strings.TrimSpace(scanner.Text())
-		if text != "" {
-			t.t = append(t.t, scanner.Text())
+		if text == "" {
+			continue
+		}
+		item, err := parseTodoItem(text)
+		if err != nil {
+			return nil, err
+		}
+		if item.id == "" {
+			// Written by an older version. Derive the id from the text so
+			// that it is the same each time, and on every machine, until
+			// the next save stores it.
+			item.id = legacyTodoID(text)
 		}
+		// e.g. the same line added on two machines and merged. The new id
+		// is derived too, so that it is also the same each time.
+		for n := 1; t.find(item.id) != -1; n++ {
+			item.id = legacyTodoID(text + "\x00" + strconv.Itoa(n))
+		}
+		t.t = append(t.t, item)
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
@@ -111,12 +166,7 @@ func todoLoad(path string) (*todos, error) {
 }
 
 func todoPrint(path string) error {
-	t, err := todoLoad(path)
-	if err != nil {
-		return err
-	}
-	fmt.Println(t.String())
-	return nil
+	return todoList(path, nil)
 }
 
 func todoAdd(path string, x []string) error {
@@ -124,11 +174,16 @@ func todoAdd(path string, x []string) error {
 		return fmt.Errorf("must provide todo text")
 	}
 
-	if len(x) != 1 {
-		return fmt.Errorf("trailing commands: %v", x[1:])
+	item, err := parseTodoInput(path, strings.Join(x, " "))
+	if err != nil {
+		return err
 	}
 
-	text := strings.TrimSpace(x[0])
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	t, err := todoLoad(path)
 	if err != nil {
@@ -137,39 +192,315 @@ func todoAdd(path string, x []string) error {
 
 	// deduplicate
 	for _, v := range t.t {
-		if v == text {
+		if v.text == item.text {
 			return nil
 		}
 	}
 
-	t.t = append(t.t, text)
+	item.id = t.newID()
+	t.t = append(t.t, item)
 	return t.save(path)
 }
 
+// parseTodoInput builds a new item from the text given to todo add. A
+// leading "(A)" sets the priority and "due:<date>" accepts any single-word
+// date, such as due:friday or due:+3.
+func parseTodoInput(path, text string) (*todoItem, error) {
+	item, err := parseTodoFields(path, text)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item.created = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if item.text == "" {
+		return nil, fmt.Errorf("must provide todo text")
+	}
+	if item.recur != nil && item.due.IsZero() {
+		item.due = item.recur.first(item.created)
+	}
+	return item, nil
+}
+
+// parseTodoFields parses the text given to todo add or edit, leaving unset
+// whatever isn't given, which may include the text itself.
+func parseTodoFields(path, text string) (*todoItem, error) {
+	item := &todoItem{}
+
+	text, recur, err := extractRecurrence(text)
+	if err != nil {
+		return nil, err
+	}
+	item.recur = recur
+
+	fields := strings.Fields(text)
+	if len(fields) > 0 && isPriority(fields[0]) {
+		item.priority = fields[0][1]
+		fields = fields[1:]
+	}
+
+	var words []string
+	for _, f := range fields {
+		k, v, _ := strings.Cut(f, ":")
+		switch {
+		case k == "due" && v != "":
+			due, err := parseDueDate(path, v)
+			if err != nil {
+				return nil, err
+			}
+			item.due = due
+		case k == "rec" && v != "":
+			r, err := parseRecurrence(v)
+			if err != nil {
+				return nil, err
+			}
+			item.recur = r
+		case k == "id":
+			// assigned by us
+		default:
+			words = append(words, f)
+		}
+	}
+
+	item.text = strings.Join(words, " ")
+	return item, nil
+}
+
+// parseDueDate resolves a single-word date for a todo. Unlike elsewhere, a
+// bare weekday is the next one, since todos are due in the future.
+func parseDueDate(path, s string) (time.Time, error) {
+	if wd, err := parseWeekday(strings.ToLower(s)); err == nil {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		return weekdayAfter(today.AddDate(0, 0, -1), wd), nil
+	}
+	return parseDateString(path, s)
+}
+
+// find returns the position of the item with the given id, or -1.
+func (t *todos) find(id string) int {
+	return slices.IndexFunc(t.t, func(v *todoItem) bool { return v.id == id })
+}
+
+// resolve returns the positions of the items referred to by refs, each of
+// which is an id, a position as shown when listing (starting from 1), or an
+// inclusive range of positions such as 2-4. Ids are checked first, and are
+// safer as they can't refer to a different item after the list changes. A
+// reference as long as an id is never taken for a position, so that an id
+// made of digits that was since completed isn't.
+func (t *todos) resolve(refs []string) ([]int, error) {
+	var ret []int
+	add := func(i int) {
+		if !slices.Contains(ret, i) {
+			ret = append(ret, i)
+		}
+	}
+
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if i := t.find(ref); i != -1 {
+			add(i)
+			continue
+		}
+		if len(ref) >= todoIDLen {
+			return nil, fmt.Errorf("%w: %v", ErrTodoNotFound, ref)
+		}
+
+		a, b, isRange := strings.Cut(ref, "-")
+		first, err1 := strconv.Atoi(a)
+		last, err2 := first, error(nil)
+		if isRange {
+			last, err2 = strconv.Atoi(b)
+		}
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTodoNotFound, ref)
+		}
+		if first < 1 || last > len(t.t) || first > last {
+			return nil, fmt.Errorf("%w: invalid position %v", ErrTodoNotFound, ref)
+		}
+		for i := first; i <= last; i++ {
+			add(i - 1)
+		}
+	}
+	return ret, nil
+}
+
+// newID returns an identifier not used by any item in the list.
+func (t *todos) newID() string {
+	for {
+		id := newTodoID()
+		if t.find(id) == -1 {
+			return id
+		}
+	}
+}
+
+func todoList(path string, x []string) error {
+	fs := flag.NewFlagSet("todo list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fSort := fs.String("s", "", "sort by due, priority or created")
+	fAll := fs.Bool("a", false, "include recurring items not yet due")
+
+	err := fs.Parse(x)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseTodoFilter(path, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	t, err := todoLoad(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var order []int
+	for i, v := range t.t {
+		if (*fAll || !v.hidden(today)) && filter(v) {
+			order = append(order, i)
+		}
+	}
+
+	var less func(a, b *todoItem) int
+	switch *fSort {
+	case "":
+	case "due":
+		less = func(a, b *todoItem) int { return compareOptionalTime(a.due, b.due) }
+	case "priority":
+		less = func(a, b *todoItem) int { return compareOptionalByte(a.priority, b.priority) }
+	case "created":
+		less = func(a, b *todoItem) int { return compareOptionalTime(a.created, b.created) }
+	default:
+		return fmt.Errorf("invalid sort: %v: options are [due priority created]", *fSort)
+	}
+	if less != nil {
+		slices.SortStableFunc(order, func(a, b int) int { return less(t.t[a], t.t[b]) })
+	}
+
+	for _, i := range order {
+		fmt.Printf("%v. %v: %v\n", i+1, t.t[i].id, t.t[i].display())
+	}
+	return nil
+}
+
+// parseTodoFilter returns a function matching items against every filter:
+// +project, @context, (A) for a priority, due:<date> for items due on or
+// before a date, and any other word for text containing it.
+func parseTodoFilter(path string, x []string) (func(*todoItem) bool, error) {
+	var filters []func(*todoItem) bool
+
+	for _, f := range x {
+		switch {
+		case len(f) > 1 && (f[0] == '+' || f[0] == '@'):
+			filters = append(filters, func(t *todoItem) bool { return t.hasTag(f) })
+		case isPriority(f):
+			filters = append(filters, func(t *todoItem) bool { return t.priority == f[1] })
+		case strings.HasPrefix(f, "due:"):
+			due, err := parseDueDate(path, strings.TrimPrefix(f, "due:"))
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, func(t *todoItem) bool { return !t.due.IsZero() && !t.due.After(due) })
+		default:
+			filters = append(filters, func(t *todoItem) bool {
+				return strings.Contains(strings.ToLower(t.text), strings.ToLower(f))
+			})
+		}
+	}
+
+	return func(t *todoItem) bool {
+		for _, v := range filters {
+			if !v(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// compareOptionalTime orders times with unset ones last.
+func compareOptionalTime(a, b time.Time) int {
+	switch {
+	case a.IsZero() && b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	case b.IsZero():
+		return -1
+	}
+	return a.Compare(b)
+}
+
+// compareOptionalByte orders values with unset ones last.
+func compareOptionalByte(a, b byte) int {
+	switch {
+	case a == b:
+		return 0
+	case a == 0:
+		return 1
+	case b == 0:
+		return -1
+	}
+	return int(a) - int(b)
+}
+
+// resolveIDs returns the positions of the items with the given ids. Unlike
+// resolve, it doesn't accept positions, for where one could refer to another
+// item than was shown, such as in the web UI.
+func (t *todos) resolveIDs(ids []string) ([]int, error) {
+	var ret []int
+	for _, id := range ids {
+		i := t.find(id)
+		if i == -1 {
+			return nil, fmt.Errorf("%w: %v", ErrTodoNotFound, id)
+		}
+		if !slices.Contains(ret, i) {
+			ret = append(ret, i)
+		}
+	}
+	return ret, nil
+}
+
 func todoComplete(path string, x []string) error {
 	if len(x) == 0 {
-		return fmt.Errorf("must provide todo item number")
+		return fmt.Errorf("must provide todo item id")
 	}
+	return completeTodos(path, x, false)
+}
 
-	if len(x) != 1 {
-		return fmt.Errorf("trailing commands: %v", x[1:])
+// completeTodos completes the items refs refer to, by id, position or range,
+// or only by id if exact.
+func completeTodos(path string, refs []string, exact bool) error {
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	item := strings.TrimSpace(x[0])
-	no, err := strconv.Atoi(item)
+	t, err := todoLoad(path)
 	if err != nil {
 		return err
 	}
 
-	t, err := todoLoad(path)
+	resolve := t.resolve
+	if exact {
+		resolve = t.resolveIDs
+	}
+	positions, err := resolve(refs)
 	if err != nil {
 		return err
 	}
 
-	if no >= len(t.t) {
-		return fmt.Errorf("invalid index %v", no)
+	var items []*todoItem
+	for _, i := range positions {
+		items = append(items, t.t[i])
 	}
+	t.t = slices.DeleteFunc(t.t, func(v *todoItem) bool { return slices.Contains(items, v) })
 
-	t.t = slices.Delete(t.t, no, no+1)
-	return t.save(path)
+	return todoArchive(path, t, items...)
 }
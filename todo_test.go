@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTodoItem(t *testing.T) {
+	line := "(A) 2026-01-06 Review pull requests +work @office due:2026-01-09 id:3fa2c1"
+
+	item, err := parseTodoItem(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.priority != 'A' || item.id != "3fa2c1" {
+		t.Fatal("invalid priority or id", item)
+	}
+	if !item.created.Equal(time.Date(2026, 1, 6, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("invalid created date", item.created)
+	}
+	if !item.due.Equal(time.Date(2026, 1, 9, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("invalid due date", item.due)
+	}
+	if item.text != "Review pull requests +work @office" {
+		t.Fatal("invalid text", item.text)
+	}
+	if !item.hasTag("+work") || !item.hasTag("@Office") || item.hasTag("+home") {
+		t.Fatal("invalid tags", item.tags("+"), item.tags("@"))
+	}
+	if item.String() != line {
+		t.Fatal("invalid output:", item.String())
+	}
+}
+
+func TestParseTodoItemPlain(t *testing.T) {
+	item, err := parseTodoItem("call the bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.priority != 0 || !item.created.IsZero() || !item.due.IsZero() || item.id != "" {
+		t.Fatal("unexpected fields", item)
+	}
+	if item.String() != "call the bank" {
+		t.Fatal("invalid output:", item.String())
+	}
+}
+
+func TestParseTodoItemUnparseable(t *testing.T) {
+	tests := map[string]string{
+		"call bob due:friday":      "call bob due:friday",
+		"(A)":                      "(A)",
+		"water plants rec:sundays": "water plants rec:sundays",
+	}
+
+	for line, want := range tests {
+		item, err := parseTodoItem(line)
+		if err != nil {
+			t.Errorf("%v: %v", line, err)
+			continue
+		}
+		if item.text != want || !item.due.IsZero() || item.recur != nil {
+			t.Errorf("%v: unexpected item %+v", line, item)
+		}
+		if item.String() != line {
+			t.Errorf("%v: invalid output: %v", line, item.String())
+		}
+	}
+
+	if _, err := parseTodoItem(""); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseTodoItemCompleted(t *testing.T) {
+	line := "x 2026-01-07 2026-01-06 Review pull requests pri:A id:3fa2c1"
+
+	item, err := parseTodoItem(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.completed.Equal(time.Date(2026, 1, 7, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("invalid completed date", item.completed)
+	}
+	if item.priority != 'A' || item.text != "Review pull requests" {
+		t.Fatal("invalid priority or text", item)
+	}
+	if item.String() != line {
+		t.Fatal("invalid output:", item.String())
+	}
+}
+
+func TestMoveWrite(t *testing.T) {
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, tagebuchTodo), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the done log can't be written over a directory
+	if err := os.Mkdir(filepath.Join(path, tagebuchDone), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	from := &todos{file: tagebuchTodo, t: []*todoItem{{text: "two"}}}
+	to := &todos{file: tagebuchDone, t: []*todoItem{{text: "one"}}}
+	if err := moveWrite(path, from, to); err == nil {
+		t.Fatal("expected error")
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, tagebuchTodo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\ntwo\n" {
+		t.Fatalf("list not restored: %q", data)
+	}
+}
+
+func TestTodoEditKeepsFields(t *testing.T) {
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, tagebuchMagic), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	line := "(B) 2026-01-06 send invoice due:2026-02-01 rec:1m id:3fa2c1\n"
+	if err := os.WriteFile(filepath.Join(path, tagebuchTodo), []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := todoEdit(path, []string{"3fa2c1", "send", "the", "invoice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := todoRead(path, tagebuchTodo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := l.t[0]
+	if item.text != "send the invoice" || item.id != "3fa2c1" || item.priority != 'B' {
+		t.Fatal("invalid item", item)
+	}
+	if !item.due.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local)) || item.recur == nil {
+		t.Fatal("due date or recurrence lost", item)
+	}
+	if !item.created.Equal(time.Date(2026, 1, 6, 0, 0, 0, 0, time.Local)) {
+		t.Fatal("invalid created date", item.created)
+	}
+	// a range would only edit its first item
+	if err := todoAdd(path, []string{"water", "plants"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := todoEdit(path, []string{"1-2", "pay", "rent"}); err == nil {
+		t.Fatal("expected error editing a range")
+	}
+}
+
+func TestTodoResolve(t *testing.T) {
+	l := &todos{t: []*todoItem{{id: "3fa2c1"}, {id: "000002"}, {id: "b"}}}
+
+	got, err := l.resolve([]string{"000002", "1", "2-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1, 0, 2}) {
+		t.Fatal("invalid positions", got)
+	}
+
+	// a completed id made of digits isn't a position
+	if _, err := l.resolve([]string{"000003"}); !errors.Is(err, ErrTodoNotFound) {
+		t.Fatal("invalid or missing error", err)
+	}
+
+	if _, err := l.resolveIDs([]string{"2"}); !errors.Is(err, ErrTodoNotFound) {
+		t.Fatal("invalid or missing error", err)
+	}
+	got, err = l.resolveIDs([]string{"b"})
+	if err != nil || !slices.Equal(got, []int{2}) {
+		t.Fatal("invalid positions", got, err)
+	}
+}
+
+func TestTodoReadDuplicateIDs(t *testing.T) {
+	path := t.TempDir()
+	data := "water plants\nwater plants\nwater plants\npay rent\n"
+	if err := os.WriteFile(filepath.Join(path, tagebuchTodo), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids [][]string
+	for range 2 {
+		l, err := todoRead(path, tagebuchTodo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v []string
+		for _, item := range l.t {
+			v = append(v, item.id)
+		}
+		ids = append(ids, v)
+	}
+
+	if !slices.Equal(ids[0], ids[1]) {
+		t.Fatal("ids changed between reads", ids)
+	}
+	if slices.Contains(ids[0][1:3], ids[0][0]) || ids[0][1] == ids[0][2] {
+		t.Fatal("duplicate ids", ids[0])
+	}
+}
+
+func TestTodoArchiveKeepsDate(t *testing.T) {
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, tagebuchMagic), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	given := &todoItem{id: "aaaaaa", text: "pay rent", completed: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)}
+	other := &todoItem{id: "bbbbbb", text: "water plants"}
+	if err := todoArchive(path, &todos{file: tagebuchTodo}, given, other); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := doneLoad(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.t) != 2 || !d.t[0].completed.Equal(given.completed) || d.t[1].completed.IsZero() || d.t[1].completed.Equal(given.completed) {
+		t.Fatal("invalid completion dates", d.t[0].completed, d.t[1].completed)
+	}
+}
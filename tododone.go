@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	tagebuchDone = "done"
+
+	// configTodoLog records completed todos in that day's entry when true.
+	configTodoLog = "todo_log"
+)
+
+// doneLoad reads the log of completed todos, which may not exist yet. It
+// does not sync, as it is always read alongside the todo list.
+func doneLoad(path string) (*todos, error) {
+	d, err := todoRead(path, tagebuchDone)
+	if errors.Is(err, os.ErrNotExist) {
+		return &todos{file: tagebuchDone}, nil
+	}
+	return d, err
+}
+
+// todoArchive moves items already removed from t to the done log, then
+// saves both.
+func todoArchive(path string, t *todos, items ...*todoItem) error {
+	d, err := doneLoad(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	for _, v := range items {
+		// keep a date given when marking it done, as in todo edit
+		if v.completed.IsZero() {
+			v.completed = today
+		}
+		d.t = append(d.t, v)
+
+		if v.recur != nil {
+			next := v.nextInstance(today)
+			next.id = t.newID()
+			t.t = append(t.t, next)
+		}
+	}
+
+	err = moveWrite(path, t, d)
+	if err != nil {
+		return err
+	}
+
+	log, err := todoLogEnabled(path)
+	if err != nil {
+		return err
+	}
+	if log {
+		for _, v := range items {
+			err = appendEntry(path, dayPath(path, now), "completed: "+v.display())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return nil
+}
+
+// moveWrite saves from, which items were moved out of, and then to, which
+// they were moved into. Should to fail, from is put back as it was, so that
+// an item is never left in both, or in neither.
+func moveWrite(path string, from, to *todos) error {
+	name := filepath.Join(path, from.file)
+	old, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	existed := err == nil
+
+	err = from.write(path)
+	if err != nil {
+		return err
+	}
+
+	err = to.write(path)
+	if err == nil {
+		return nil
+	}
+
+	var rerr error
+	if existed {
+		rerr = writeFileAtomic(name, bytes.NewReader(old))
+	} else {
+		rerr = os.Remove(name)
+	}
+	if rerr != nil {
+		return fmt.Errorf("%w, and restoring %v failed: %v", err, from.file, rerr)
+	}
+	return err
+}
+
+func todoLogEnabled(path string) (bool, error) {
+	c, err := getConfig(path)
+	if err != nil {
+		return false, err
+	}
+	return c.todoLog, nil
+}
+
+func todoDone(path string, x []string) error {
+	filter, err := parseTodoFilter(path, x)
+	if err != nil {
+		return err
+	}
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	d, err := doneLoad(path)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range d.t {
+		if filter(v) {
+			fmt.Printf("%v %v %v\n", v.id, v.completed.Format(isoDate), v.display())
+		}
+	}
+	return nil
+}
+
+func todoReopen(path string, x []string) error {
+	if len(x) == 0 {
+		return fmt.Errorf("must provide todo item id")
+	}
+
+	if len(x) != 1 {
+		return fmt.Errorf("trailing commands: %v", x[1:])
+	}
+
+	id := strings.TrimSpace(x[0])
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	t, err := todoLoad(path)
+	if err != nil {
+		return err
+	}
+
+	d, err := doneLoad(path)
+	if err != nil {
+		return err
+	}
+
+	i := d.find(id)
+	if i == -1 {
+		return fmt.Errorf("%w: %v", ErrTodoNotFound, id)
+	}
+
+	item := d.t[i]
+	d.t = slices.Delete(d.t, i, i+1)
+	item.completed = time.Time{}
+	if t.find(item.id) != -1 {
+		item.id = t.newID()
+	}
+	t.t = append(t.t, item)
+
+	err = moveWrite(path, d, t)
+	if err != nil {
+		return err
+	}
+
+	err = syncPush(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return nil
+}
+
+func todoPurge(path string, x []string) error {
+	fs := flag.NewFlagSet("todo purge", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fBefore := fs.String("before", "", "remove items completed before this date")
+
+	err := fs.Parse(x)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("trailing commands: %v", fs.Args())
+	}
+	if *fBefore == "" {
+		return fmt.Errorf("usage: todo purge -before <date>")
+	}
+
+	before, err := parseDateString(path, *fBefore)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = syncPull(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	d, err := doneLoad(path)
+	if err != nil {
+		return err
+	}
+
+	n := len(d.t)
+	d.t = slices.DeleteFunc(d.t, func(v *todoItem) bool { return v.completed.Before(before) })
+	if len(d.t) == n {
+		return nil
+	}
+
+	fmt.Printf("purged %v completed todos\n", n-len(d.t))
+	return d.save(path)
+}
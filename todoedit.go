@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func todoEdit(path string, x []string) error {
+	if len(x) == 0 {
+		return todoEditAll(path)
+	}
+
+	if len(x) == 1 {
+		return fmt.Errorf("usage: todo edit <id> <text>")
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	t, err := todoLoad(path)
+	if err != nil {
+		return err
+	}
+
+	positions, err := t.resolve(x[:1])
+	if err != nil {
+		return err
+	}
+	if len(positions) != 1 {
+		return fmt.Errorf("can only edit one item")
+	}
+
+	edited, err := parseTodoFields(path, strings.Join(x[1:], " "))
+	if err != nil {
+		return err
+	}
+	if !t.t[positions[0]].merge(edited) {
+		return fmt.Errorf("usage: todo edit <id> <text>")
+	}
+
+	return t.save(path)
+}
+
+// merge sets what was given in edited, leaving the rest of the item, and its
+// identity, as it was. It returns false if nothing was given.
+func (t *todoItem) merge(edited *todoItem) bool {
+	given := false
+	if edited.text != "" {
+		t.text = edited.text
+		given = true
+	}
+	if edited.priority != 0 {
+		t.priority = edited.priority
+		given = true
+	}
+	if !edited.due.IsZero() {
+		t.due = edited.due
+		given = true
+	}
+	if edited.recur != nil {
+		t.recur = edited.recur
+		given = true
+	}
+	return given
+}
+
+// todoEditAll opens the whole list in the editor as todo.txt lines. Every line
+// must be valid for any change to be saved. Lines marked completed ("x" and
+// a date) are moved to the done log. The journal isn't locked while editing,
+// so if the list changes meanwhile nothing is saved and the edited copy is
+// kept.
+func todoEditAll(path string) error {
+	editor, err := getEditor(path)
+	if err != nil {
+		return err
+	}
+
+	t, err := todoLoad(path)
+	if err != nil {
+		return err
+	}
+
+	pt := filepath.Join(path, tagebuchTodo)
+	before, err := os.ReadFile(pt)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "tb-todo-*.txt")
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(f.Name())
+		}
+	}()
+
+	for _, v := range t.t {
+		_, err = f.WriteString(v.String() + "\n")
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = runEditor(editor, f.Name())
+	if err != nil {
+		return err
+	}
+
+	edited, err := todoRead("", f.Name())
+	if err != nil {
+		return fmt.Errorf("%w: no changes saved", err)
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	after, err := os.ReadFile(pt)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(before, after) {
+		keep = true
+		return fmt.Errorf("todo list changed while editing, no changes saved. Edited copy is in %v", f.Name())
+	}
+
+	var done []*todoItem
+	t.t = slices.DeleteFunc(edited.t, func(v *todoItem) bool {
+		if v.completed.IsZero() {
+			return false
+		}
+		done = append(done, v)
+		return true
+	})
+
+	if len(done) > 0 {
+		return todoArchive(path, t, done...)
+	}
+	return t.save(path)
+}
+
+func todoMove(path string, x []string) error {
+	if len(x) != 2 {
+		return fmt.Errorf("usage: todo move <id> <position>")
+	}
+
+	to, err := strconv.Atoi(x[1])
+	if err != nil {
+		return fmt.Errorf("invalid position: %v", x[1])
+	}
+
+	unlock, err := lockJournal(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	t, err := todoLoad(path)
+	if err != nil {
+		return err
+	}
+
+	positions, err := t.resolve(x[:1])
+	if err != nil {
+		return err
+	}
+	if len(positions) != 1 {
+		return fmt.Errorf("can only move one item")
+	}
+
+	if to < 1 || to > len(t.t) {
+		return fmt.Errorf("invalid position: %v", to)
+	}
+
+	from := positions[0]
+	item := t.t[from]
+	t.t = slices.Delete(t.t, from, from+1)
+	t.t = slices.Insert(t.t, to-1, item)
+
+	return t.save(path)
+}
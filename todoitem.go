@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// isoDate is the date layout used in the todo file, as in todo.txt.
+const isoDate = "2006-01-02"
+
+// todoItem is a single todo, stored one per line in todo.txt format:
+//
+//	(A) 2026-01-06 Review pull requests +work @office due:2026-01-09 id:3fa2c1
+//
+// Priority and creation date are optional, as are tags in the text. Lines
+// without any of these, as written by older versions, are plain text.
+// Completed items are prefixed with "x" and the completion date, and their
+// priority moves to a pri:A tag, also as in todo.txt.
+type todoItem struct {
+	id        string
+	priority  byte // 'A' (highest) to 'Z', or 0 for none
+	created   time.Time
+	completed time.Time
+	due       time.Time
+	recur     *recurrence // nil unless the item repeats
+	text      string      // description, including +project and @context tags
+}
+
+// todoIDLen is the length of the identifiers given to todo items.
+const todoIDLen = 6
+
+// newTodoID returns a short random identifier for a todo item.
+func newTodoID() string {
+	b := make([]byte, todoIDLen/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// legacyTodoID returns an identifier derived from a line without one.
+func legacyTodoID(line string) string {
+	sum := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(sum[:todoIDLen/2])
+}
+
+// parseTodoItem parses a single line of the todo file. Anything that can't
+// be parsed, such as "due:friday" in a line written by an older version, is
+// kept as text.
+func parseTodoItem(line string) (*todoItem, error) {
+	item := &todoItem{}
+	fields := strings.Fields(line)
+
+	if len(fields) > 1 && fields[0] == "x" {
+		if t, err := time.ParseInLocation(isoDate, fields[1], time.Local); err == nil {
+			item.completed = t
+			fields = fields[2:]
+		}
+	}
+
+	if len(fields) > 0 && isPriority(fields[0]) {
+		item.priority = fields[0][1]
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 {
+		if t, err := time.ParseInLocation(isoDate, fields[0], time.Local); err == nil {
+			item.created = t
+			fields = fields[1:]
+		}
+	}
+
+	var text []string
+	for _, f := range fields {
+		k, v, ok := strings.Cut(f, ":")
+		switch {
+		case ok && k == "id" && v != "":
+			item.id = v
+		case ok && k == "pri" && isPriority("("+v+")"):
+			item.priority = v[0]
+		case ok && k == "rec" && v != "":
+			r, err := parseRecurrence(v)
+			if err != nil {
+				text = append(text, f)
+				continue
+			}
+			item.recur = r
+		case ok && k == "due" && v != "":
+			t, err := time.ParseInLocation(isoDate, v, time.Local)
+			if err != nil {
+				text = append(text, f)
+				continue
+			}
+			item.due = t
+		default:
+			text = append(text, f)
+		}
+	}
+	item.text = strings.Join(text, " ")
+
+	if item.text == "" {
+		// e.g. a line that is only "(A)"
+		item = &todoItem{text: strings.Join(strings.Fields(line), " ")}
+	}
+	if item.text == "" {
+		return nil, fmt.Errorf("invalid todo item: %v", line)
+	}
+	return item, nil
+}
+
+// isPriority reports whether s is a todo.txt priority such as "(A)".
+func isPriority(s string) bool {
+	return len(s) == 3 && s[0] == '(' && s[2] == ')' && s[1] >= 'A' && s[1] <= 'Z'
+}
+
+// String returns the item as a line of the todo file.
+func (t *todoItem) String() string {
+	var ret []string
+	if !t.completed.IsZero() {
+		ret = append(ret, "x", t.completed.Format(isoDate))
+	} else if t.priority != 0 {
+		ret = append(ret, fmt.Sprintf("(%c)", t.priority))
+	}
+	if !t.created.IsZero() {
+		ret = append(ret, t.created.Format(isoDate))
+	}
+	ret = append(ret, t.text)
+	if !t.due.IsZero() {
+		ret = append(ret, "due:"+t.due.Format(isoDate))
+	}
+	if t.recur != nil {
+		ret = append(ret, "rec:"+t.recur.String())
+	}
+	if !t.completed.IsZero() && t.priority != 0 {
+		ret = append(ret, fmt.Sprintf("pri:%c", t.priority))
+	}
+	if t.id != "" {
+		ret = append(ret, "id:"+t.id)
+	}
+	return strings.Join(ret, " ")
+}
+
+// MarshalJSON encodes the item for the web API.
+func (t *todoItem) MarshalJSON() ([]byte, error) {
+	v := struct {
+		ID        string `json:"id"`
+		Text      string `json:"text"`
+		Priority  string `json:"priority,omitempty"`
+		Created   string `json:"created,omitempty"`
+		Completed string `json:"completed,omitempty"`
+		Due       string `json:"due,omitempty"`
+		Recur     string `json:"recur,omitempty"`
+	}{
+		ID:   t.id,
+		Text: t.text,
+	}
+	if t.priority != 0 {
+		v.Priority = string(t.priority)
+	}
+	if !t.created.IsZero() {
+		v.Created = t.created.Format(isoDate)
+	}
+	if !t.completed.IsZero() {
+		v.Completed = t.completed.Format(isoDate)
+	}
+	if !t.due.IsZero() {
+		v.Due = t.due.Format(isoDate)
+	}
+	if t.recur != nil {
+		v.Recur = t.recur.String()
+	}
+	return json.Marshal(v)
+}
+
+// display returns the item as shown in listings, without its bookkeeping.
+func (t *todoItem) display() string {
+	var ret []string
+	if t.priority != 0 {
+		ret = append(ret, fmt.Sprintf("(%c)", t.priority))
+	}
+	ret = append(ret, t.text)
+	if !t.due.IsZero() {
+		ret = append(ret, "due:"+t.due.Format(isoDate))
+	}
+	if t.recur != nil {
+		ret = append(ret, "rec:"+t.recur.String())
+	}
+	return strings.Join(ret, " ")
+}
+
+// hidden reports whether a recurring item's next occurrence is still to
+// come, in which case it isn't listed by default.
+func (t *todoItem) hidden(today time.Time) bool {
+	return t.recur != nil && t.due.After(today)
+}
+
+// tags returns the words in the item's text starting with prefix, such as
+// "+" for projects or "@" for contexts.
+func (t *todoItem) tags(prefix string) []string {
+	var ret []string
+	for _, f := range strings.Fields(t.text) {
+		if len(f) > len(prefix) && strings.HasPrefix(f, prefix) {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+func (t *todoItem) hasTag(tag string) bool {
+	for _, v := range t.tags(tag[:1]) {
+		if strings.EqualFold(v, tag) {
+			return true
+		}
+	}
+	return false
+}
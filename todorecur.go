@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recurrence is how often a todo repeats. It is stored as a rec: tag in the
+// todo file, in one of three forms:
+//
+//	rec:2w   every 2 weeks (also d, m and y for days, months and years)
+//	rec:mon  every monday
+//	rec:1st  every month on the 1st
+type recurrence struct {
+	every    int  // interval, for unit
+	unit     byte // 'd', 'w', 'm' or 'y', or 0 if on a weekday or day of the month
+	weekday  time.Weekday
+	weekly   bool // on weekday
+	monthDay int  // monthly on this day, if non-zero
+}
+
+// Recurrences written in words must end the text, apart from any tags, so
+// that the same words elsewhere, as in "ask about every day use", are text.
+var (
+	reEveryUnit    = regexp.MustCompile(`(?i)\bevery\s+(?:(\d+)\s+)?(day|week|month|year)s?$`)
+	reEveryWeekday = regexp.MustCompile(`(?i)\bevery\s+(sun|mon|tue|wed|thu|fri|sat)[a-z]*$`)
+	reMonthlyOn    = regexp.MustCompile(`(?i)\bmonthly\s+on\s+(?:the\s+)?(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+func parseRecurrence(spec string) (*recurrence, error) {
+	spec = strings.ToLower(spec)
+	invalid := fmt.Errorf("invalid recurrence: %v", spec)
+
+	if len(spec) < 2 {
+		return nil, invalid
+	}
+
+	if wd, err := parseWeekday(spec); err == nil && len(spec) >= 3 {
+		return &recurrence{weekly: true, weekday: wd}, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimRight(spec, "abcdefghijklmnopqrstuvwxyz"))
+	if err != nil || n < 1 {
+		return nil, invalid
+	}
+	suffix := strings.TrimLeft(spec, "0123456789")
+
+	switch suffix {
+	case "d", "w", "m", "y":
+		return &recurrence{every: n, unit: suffix[0]}, nil
+	case "st", "nd", "rd", "th":
+		if n > 31 {
+			return nil, invalid
+		}
+		return &recurrence{monthDay: n}, nil
+	}
+	return nil, invalid
+}
+
+// extractRecurrence finds a recurrence written in words at the end of text,
+// before any +project, @context or key:value tags, such as "every monday",
+// "every 2 weeks" or "monthly on 1st", and returns the text without it. The
+// recurrence is nil if there is none.
+func extractRecurrence(text string) (string, *recurrence, error) {
+	var r *recurrence
+	var loc []int
+
+	fields := strings.Fields(text)
+	n := len(fields)
+	for n > 0 && isTodoTag(fields[n-1]) {
+		n--
+	}
+	text = strings.Join(fields[:n], " ")
+	tags := fields[n:]
+
+	if m := reEveryUnit.FindStringSubmatchIndex(text); m != nil {
+		n := 1
+		if m[2] != -1 {
+			n, _ = strconv.Atoi(text[m[2]:m[3]])
+		}
+		if n < 1 {
+			return "", nil, fmt.Errorf("invalid recurrence: %v", text[m[0]:m[1]])
+		}
+		r = &recurrence{every: n, unit: strings.ToLower(text[m[4]:m[5]])[0]}
+		loc = m
+	} else if m := reEveryWeekday.FindStringSubmatchIndex(text); m != nil {
+		wd, err := parseWeekday(strings.ToLower(text[m[2]:m[3]]))
+		if err != nil {
+			return "", nil, err
+		}
+		r = &recurrence{weekly: true, weekday: wd}
+		loc = m
+	} else if m := reMonthlyOn.FindStringSubmatchIndex(text); m != nil {
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		if n < 1 || n > 31 {
+			return "", nil, fmt.Errorf("invalid recurrence: %v", text[m[0]:m[1]])
+		}
+		r = &recurrence{monthDay: n}
+		loc = m
+	} else {
+		return strings.Join(fields, " "), nil, nil
+	}
+
+	text = strings.Join(append(strings.Fields(text[:loc[0]]), tags...), " ")
+	return text, r, nil
+}
+
+// isTodoTag reports whether a word of a todo is a +project or @context tag,
+// or a key:value such as due:friday.
+func isTodoTag(s string) bool {
+	if len(s) > 1 && (s[0] == '+' || s[0] == '@') {
+		return true
+	}
+	k, v, ok := strings.Cut(s, ":")
+	return ok && k != "" && v != ""
+}
+
+// String returns the recurrence as stored in a rec: tag.
+func (r *recurrence) String() string {
+	switch {
+	case r.weekly:
+		return dateWords[3+int(r.weekday)][:3]
+	case r.monthDay != 0:
+		return ordinal(r.monthDay)
+	}
+	return fmt.Sprintf("%d%c", r.every, r.unit)
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// next returns the first occurrence strictly after t.
+func (r *recurrence) next(t time.Time) time.Time {
+	switch {
+	case r.weekly:
+		return weekdayAfter(t, r.weekday)
+	case r.monthDay != 0:
+		for i := 0; ; i++ {
+			// clamp to the end of shorter months
+			first := time.Date(t.Year(), t.Month()+time.Month(i), 1, 0, 0, 0, 0, t.Location())
+			day := min(r.monthDay, daysIn(int(first.Month()), first.Year()))
+			next := first.AddDate(0, 0, day-1)
+			if next.After(t) {
+				return next
+			}
+		}
+	}
+
+	switch r.unit {
+	case 'd':
+		return t.AddDate(0, 0, r.every)
+	case 'w':
+		return t.AddDate(0, 0, 7*r.every)
+	case 'm':
+		return addMonths(t, r.every)
+	default:
+		return addMonths(t, 12*r.every)
+	}
+}
+
+// addMonths adds n months to t, clamping to the end of a shorter month
+// rather than overflowing into the next, so that a month after January 31st
+// is February 28th rather than March 3rd. As only the due date is stored, the
+// month after that is March 28th; "monthly on 31st" keeps to the last day.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	day := min(t.Day(), daysIn(int(first.Month()), first.Year()))
+	return first.AddDate(0, 0, day-1)
+}
+
+// first returns the first occurrence on or after today.
+func (r *recurrence) first(today time.Time) time.Time {
+	if r.unit != 0 {
+		return today
+	}
+	return r.next(today.AddDate(0, 0, -1))
+}
+
+// nextInstance returns a new copy of a recurring item, due at its next
+// occurrence after both its current due date and today.
+func (t *todoItem) nextInstance(today time.Time) *todoItem {
+	due := t.due
+	if due.IsZero() {
+		due = today
+	}
+	due = t.recur.next(due)
+	for !due.After(today) {
+		due = t.recur.next(due)
+	}
+
+	return &todoItem{
+		priority: t.priority,
+		created:  today,
+		due:      due,
+		text:     t.text,
+		recur:    t.recur,
+	}
+}
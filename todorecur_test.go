@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExtractRecurrence(t *testing.T) {
+	tests := map[string][2]string{
+		"weekly report every monday +work": {"weekly report +work", "mon"},
+		"water plants every 2 weeks @home": {"water plants @home", "2w"},
+		"every 2 weeks water plants":       {"every 2 weeks water plants", ""},
+		"ask about every day use":          {"ask about every day use", ""},
+		"pay rent every month":             {"pay rent", "1m"},
+		"invoice monthly on the 1st":       {"invoice", "1st"},
+		"no recurrence here":               {"no recurrence here", ""},
+	}
+
+	for in, want := range tests {
+		text, r, err := extractRecurrence(in)
+		if err != nil {
+			t.Errorf("%v: %v", in, err)
+			continue
+		}
+		spec := ""
+		if r != nil {
+			spec = r.String()
+		}
+		if text != want[0] || spec != want[1] {
+			t.Errorf("%v: got %q %q, want %q %q", in, text, spec, want[0], want[1])
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// a wednesday
+	now := time.Date(2026, 1, 7, 0, 0, 0, 0, time.Local)
+
+	tests := map[string]string{
+		"mon":  "2026/1/12",
+		"wed":  "2026/1/14",
+		"3d":   "2026/1/10",
+		"2w":   "2026/1/21",
+		"1m":   "2026/2/7",
+		"1st":  "2026/2/1",
+		"31st": "2026/1/31",
+	}
+
+	for spec, want := range tests {
+		r, err := parseRecurrence(spec)
+		if err != nil {
+			t.Errorf("%v: %v", spec, err)
+			continue
+		}
+		if got := dateString(r.next(now)); got != want {
+			t.Errorf("%v: got %v, want %v", spec, got, want)
+		}
+	}
+
+	// clamped to the end of february
+	r, _ := parseRecurrence("31st")
+	if got := dateString(r.next(time.Date(2026, 1, 31, 0, 0, 0, 0, time.Local))); got != "2026/2/28" {
+		t.Fatal("invalid clamped date", got)
+	}
+
+	clamped := map[string][2]string{
+		"1m": {"2026/1/31", "2026/2/28"},
+		"3m": {"2025/11/30", "2026/2/28"},
+		"1y": {"2028/2/29", "2029/2/28"},
+	}
+	for spec, v := range clamped {
+		r, _ := parseRecurrence(spec)
+		from, _ := parseDate(v[0], time.Now())
+		if got := dateString(r.next(from)); got != v[1] {
+			t.Errorf("%v from %v: got %v, want %v", spec, v[0], got, v[1])
+		}
+	}
+}